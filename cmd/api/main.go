@@ -5,11 +5,15 @@ package main
 import (
 	"fmt"
 	"log"
+	_ "time/tzdata" // Embedded IANA zones: user timezones are validated on images without zoneinfo
 
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
 	"github.com/Ankit1974/TaskDeskBackend/internal/api/router"
 	"github.com/Ankit1974/TaskDeskBackend/internal/config"
 	"github.com/Ankit1974/TaskDeskBackend/internal/db"
 	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/mailer"
+	"github.com/Ankit1974/TaskDeskBackend/internal/storage"
 )
 
 func main() {
@@ -29,10 +33,20 @@ func main() {
 	db.InitDB(cfg.DatabaseURL)
 	defer db.CloseDB()
 
-	// 4. Setup Router — registers all API routes, middleware chains, and handler functions.
+	// 4. Initialize Auth — configures JWT verification (HMAC secret and/or JWKS keys,
+	//    issuer/audience checks) and warms the JWKS cache if one is configured.
+	middleware.InitAuth()
+
+	// 5. Initialize Mailer — selects the email sender (SMTP, or logging for development).
+	mailer.InitMailer(cfg)
+
+	// 6. Initialize Storage — selects the attachment store (local directory or S3 bucket).
+	storage.InitStorage(cfg)
+
+	// 7. Setup Router — registers all API routes, middleware chains, and handler functions.
 	r := router.SetupRouter()
 
-	// 5. Run Server — starts listening on the configured port (default: 8080).
+	// 8. Run Server — starts listening on the configured port (default: 8080).
 	addr := fmt.Sprintf(":%s", cfg.AppPort)
 	logger.Log.Info(fmt.Sprintf("Server is running on %s", addr))
 	if err := r.Run(addr); err != nil {
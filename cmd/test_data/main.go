@@ -36,41 +36,32 @@ func main() {
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	email := fmt.Sprintf("testuser_%d@example.com", time.Now().Unix())
+	runID := time.Now().Unix()
+	email := fmt.Sprintf("testuser_%d@example.com", runID)
 
-	// 2. Register User
-	log.Println("--- Step 1: Registering User ---")
+	// 2. Generate JWT (registration requires one: it binds the user to the token's "sub")
+	log.Println("--- Step 1: Generating JWT ---")
+	tokenString := signToken(email)
+	log.Println("JWT generated successfully")
+
+	// 3. Register User (founding a new organisation makes the user its Admin,
+	// which is allowed to create projects)
+	log.Println("--- Step 2: Registering User ---")
 	regBody := map[string]string{
 		"full_name":         "Test User",
-		"email":             email,
-		"organisation_name": "Test Org",
-		"role":              "PM",
+		"organisation_name": fmt.Sprintf("Test Org %d", runID),
 	}
-	resp, err := makeRequest(client, "POST", "/register", regBody, "")
+	resp, err := makeRequest(client, "POST", "/register", regBody, tokenString)
 	if err != nil {
 		log.Fatalf("Registration failed: %v", err)
 	}
 	defer resp.Body.Close()
+	regRespBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		log.Fatalf("Registration failed with status %d: %s", resp.StatusCode, string(body))
+		log.Fatalf("Registration failed with status %d: %s", resp.StatusCode, string(regRespBody))
 	}
 	log.Printf("User registered: %s\n", email)
 
-	// 3. Generate JWT
-	log.Println("--- Step 2: Generating JWT ---")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   uuid.NewString(), // Random Supabase user ID
-		"email": email,
-		"exp":   time.Now().Add(time.Hour).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(config.Cfg.SupabaseJWTSecret))
-	if err != nil {
-		log.Fatalf("Failed to sign token: %v", err)
-	}
-	log.Println("JWT generated successfully")
-
 	// 4. Create Project
 	log.Println("--- Step 3: Creating Project ---")
 	projBody := map[string]interface{}{
@@ -140,6 +131,23 @@ func main() {
 	log.Println("Test script completed successfully!")
 }
 
+// signToken creates a Supabase-style HS256 access token for the given email.
+// The "sub" claim is derived from the email so the same user always gets the same
+// Supabase user ID across runs (registrations are bound to it).
+func signToken(email string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   uuid.NewSHA1(uuid.NameSpaceURL, []byte("mailto:"+email)).String(),
+		"email": email,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(config.Cfg.SupabaseJWTSecret))
+	if err != nil {
+		log.Fatalf("Failed to sign token: %v", err)
+	}
+	return tokenString
+}
+
 func makeRequest(client *http.Client, method, path string, body interface{}, token string) (*http.Response, error) {
 	var bodyReader io.Reader
 	if body != nil {
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// recordActivity appends an event to the project's activity feed. It must run in the
+// transaction that makes the change, so that the feed never shows events that did not happen.
+// payload is encoded as JSON; nil is stored as {}.
+func recordActivity(ctx context.Context, q querier, projectID, actorID, eventType string, bugID *string, payload any) error {
+	encoded := []byte("{}")
+	if payload != nil {
+		var err error
+		if encoded, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	_, err := q.Exec(ctx, `
+		INSERT INTO project_activity (project_id, bug_id, event_type, actor_id, payload)
+		VALUES ($1, $2, $3, $4, $5::JSONB)
+	`, projectID, bugID, eventType, actorID, string(encoded))
+	return err
+}
+
+// activityFilters are the query parameters shared by the activity feeds.
+type activityFilters struct {
+	types  []string // nil = every type
+	actor  *string
+	cursor *int64
+	limit  int
+}
+
+// parseActivityFilters reads the feed's query parameters and writes a 400 response for
+// invalid values:
+//   - type:   comma-separated event types (see model.ActivityEventTypes)
+//   - actor:  registration UUID of the user who acted
+//   - cursor: next_cursor of the previous page
+//   - limit:  events per page (default: 50, max: 100)
+func parseActivityFilters(c *gin.Context) (activityFilters, bool) {
+	filters := activityFilters{limit: 50}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit >= 1 && limit <= 100 {
+		filters.limit = limit
+	}
+
+	if raw := strings.TrimSpace(c.Query("type")); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(model.ActivityEventTypes, t) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid type " + strconv.Quote(t) + ". Must be one of: " + strings.Join(model.ActivityEventTypes, ", "),
+				})
+				return filters, false
+			}
+			filters.types = append(filters.types, t)
+		}
+	}
+
+	if actor := c.Query("actor"); actor != "" {
+		if _, err := uuid.Parse(actor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor. Must be a user UUID"})
+			return filters, false
+		}
+		filters.actor = &actor
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return filters, false
+		}
+		filters.cursor = &cursor
+	}
+	return filters, true
+}
+
+// writeActivityFeed runs the feed query for the projects matched by scope (a condition on
+// "pa" whose parameters are $1 and $2) and writes the page, newest first.
+func writeActivityFeed(c *gin.Context, ctx context.Context, scope string, scopeArgs [2]any, filters activityFilters) {
+	// One extra row tells whether there is a next page
+	rows, err := db.Pool.Query(ctx, `
+		SELECT pa.id, pa.project_id, p.project_name, pa.event_type, pa.actor_id, r.full_name,
+		       pa.bug_id, pa.payload, pa.created_at
+		FROM project_activity pa
+		JOIN projects p ON p.id = pa.project_id
+		JOIN registrations r ON r.id = pa.actor_id
+		WHERE `+scope+`
+		AND ($3::TEXT[] IS NULL OR pa.event_type = ANY($3))
+		AND ($4::UUID IS NULL OR pa.actor_id = $4)
+		AND ($5::BIGINT IS NULL OR pa.id < $5)
+		ORDER BY pa.id DESC
+		LIMIT $6
+	`, scopeArgs[0], scopeArgs[1], filters.types, filters.actor, filters.cursor, filters.limit+1)
+	if err != nil {
+		logger.Log.Error("Failed to fetch activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ActivityEvent, error) {
+		var e model.ActivityEvent
+		err := row.Scan(&e.ID, &e.ProjectID, &e.ProjectName, &e.EventType, &e.ActorID, &e.ActorName,
+			&e.BugID, &e.Payload, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		logger.Log.Error("Failed to scan activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+
+	response := model.ActivityFeedResponse{Activity: events, Limit: filters.limit}
+	if len(events) > filters.limit {
+		response.Activity = events[:filters.limit]
+		next := strconv.FormatInt(response.Activity[filters.limit-1].ID, 10)
+		response.NextCursor = &next
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProjectActivity returns a page of a project's activity feed, newest first.
+// Any project role can view it. Query parameters: see parseActivityFilters.
+// Error responses: 400 (invalid filter), 404 (not found / no access), 500 (database error)
+func GetProjectActivity(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	filters, ok := parseActivityFilters(c)
+	if !ok {
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
+
+	writeActivityFeed(c, ctx, `pa.project_id = $1 AND p.organisation_id = $2`,
+		[2]any{projectID, user.OrganisationID}, filters)
+}
+
+// GetActivity returns a page of the activity of every project the user can see (projects
+// of their organisation they created or are a member of, archived ones included), newest
+// first. Query parameters: see parseActivityFilters.
+// Error responses: 400 (invalid filter), 500 (database error)
+func GetActivity(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	filters, ok := parseActivityFilters(c)
+	if !ok {
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	writeActivityFeed(c, ctx, `p.organisation_id = $2 AND pa.project_id IN (
+			SELECT id FROM projects WHERE created_by = $1
+			UNION
+			SELECT project_id FROM project_members WHERE user_id = $1
+		)`, [2]any{user.RegistrationID, user.OrganisationID}, filters)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangeUserRole promotes or demotes the role of a user in the Admin's organisation. Only
+// accessible by users with the "Admin" role. Every change is recorded in role_changes with
+// the acting admin. Users of other organisations are reported as not found.
+// Error responses: 400 (validation / unknown role), 403 (own role), 404 (user not found),
+// 409 (role unchanged), 500 (database error)
+func ChangeUserRole(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userID := c.Param("userId")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Admins changing their own role could lock everyone out of role management
+	if userID == user.RegistrationID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Administrators cannot change their own role"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, ok := model.NormalizeRole(input.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be one of: Admin, PM, Developer, QA, Designer"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Update the role and record the change atomically
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	var oldRole string
+	err = tx.QueryRow(ctx,
+		`SELECT role FROM registrations WHERE id = $1 AND organisation_id = $2 FOR UPDATE`,
+		userID, user.OrganisationID,
+	).Scan(&oldRole)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch registration: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+	if oldRole == role {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already has the " + role + " role"})
+		return
+	}
+
+	var response model.ChangeRoleResponse
+	response.User, err = scanRegistration(tx.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE registrations SET role = $1
+			WHERE id = $2
+			RETURNING id, full_name, email, organisation_id, role, supabase_user_id, is_service_account, created_at
+		)
+		SELECT `+registrationColumns+`
+		FROM updated r
+		JOIN organisations o ON o.id = r.organisation_id
+	`, role, userID))
+	if err != nil {
+		logger.Log.Error("Failed to update role: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO role_changes (user_id, old_role, new_role, changed_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, old_role, new_role, changed_by, changed_at
+	`, userID, oldRole, role, user.RegistrationID).Scan(
+		&response.Change.ID, &response.Change.UserID, &response.Change.OldRole,
+		&response.Change.NewRole, &response.Change.ChangedBy, &response.Change.ChangedAt,
+	)
+	if err != nil {
+		logger.Log.Error("Failed to record role change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit role change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	// The new role must apply to the user's next request, not after the cache TTL
+	middleware.InvalidateUser(userID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUser removes the registration of a user in the Admin's organisation. Their project
+// memberships and own API tokens are removed with it; tokens they issued to others are revoked.
+// Users who still own projects or are referenced by bugs cannot be removed (409) until
+// that work has been reassigned. Only accessible by users with the "Admin" role.
+// Error responses: 403 (own account), 404 (user not found), 409 (still referenced), 500 (database error)
+func DeleteUser(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userID := c.Param("userId")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if userID == user.RegistrationID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Administrators cannot remove their own account"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Deleting cascades to project_members, so member counts are recounted in the same transaction
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	rows, err := tx.Query(ctx, `SELECT project_id::TEXT FROM project_members WHERE user_id = $1`, userID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch memberships: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+	projectIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		logger.Log.Error("Failed to read memberships: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+
+	// Tokens the user issued (e.g. for service accounts) must not outlive their creator;
+	// deleting the registration then only clears created_by on them
+	_, err = tx.Exec(ctx, `
+		UPDATE api_tokens SET revoked_at = NOW()
+		WHERE created_by = $1 AND revoked_at IS NULL
+		AND EXISTS (SELECT 1 FROM registrations WHERE id = $1 AND organisation_id = $2)
+	`, userID, user.OrganisationID)
+	if err != nil {
+		logger.Log.Error("Failed to revoke issued tokens: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM registrations WHERE id = $1 AND organisation_id = $2`,
+		userID, user.OrganisationID,
+	)
+	if err != nil {
+		if db.IsForeignKeyViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User still owns projects or is referenced by bugs; reassign them first"})
+			return
+		}
+		logger.Log.Error("Failed to delete registration: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		if err := syncMemberCount(ctx, tx, projectID); err != nil {
+			logger.Log.Error("Failed to update member count: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit user removal: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user"})
+		return
+	}
+
+	// A removed user must not keep authenticating from the cache
+	middleware.InvalidateUser(userID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAuthCacheStats returns the hit/miss counters of the auth middleware's registration cache.
+// Only accessible by users with the "Admin" role.
+func GetAuthCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.GetCacheStats())
+}
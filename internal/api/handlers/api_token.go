@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// apiTokenColumns is the column list shared by every query that returns api_tokens rows.
+// Keep it in sync with scanAPIToken.
+const apiTokenColumns = `id, user_id, name, token_prefix, scopes, project_id, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+// defaultTokenLifetimeDays applies when a token request has no expires_in_days.
+const defaultTokenLifetimeDays = 90
+
+// scanAPIToken reads a single row selected with apiTokenColumns into a model.APIToken.
+func scanAPIToken(row pgx.Row) (model.APIToken, error) {
+	var t model.APIToken
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.Name, &t.TokenPrefix, &t.Scopes, &t.ProjectID,
+		&t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt, &t.CreatedBy, &t.CreatedAt,
+	)
+	return t, err
+}
+
+// issueAPIToken validates a token request, creates a token acting as ownerID and writes
+// the 201 response containing the secret. creator is the user who issued it; the owner
+// always belongs to the creator's organisation.
+func issueAPIToken(c *gin.Context, ownerID string, creator *middleware.UserContext) {
+	// Bind and validate the JSON request body
+	var input model.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, &input)})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	scopes := slices.Clone(input.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = defaultTokenLifetimeDays
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A project-restricted token is only useful if its owner can access the project
+	if input.ProjectID != nil {
+		access, err := loadProjectAccess(ctx, *input.ProjectID, ownerID, creator.OrganisationID)
+		if err != nil {
+			logger.Log.Error("Failed to check project access: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+		if access.Role == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Token owner is not a member of this project"})
+			return
+		}
+	}
+
+	secret, hash, prefix, err := middleware.GenerateAPIToken()
+	if err != nil {
+		logger.Log.Error("Failed to generate API token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	token, err := scanAPIToken(db.Pool.QueryRow(ctx, `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, project_id, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW() + make_interval(days => $7), $8)
+		RETURNING `+apiTokenColumns,
+		ownerID, name, hash, prefix, scopes, input.ProjectID, days, creator.RegistrationID,
+	))
+	if err != nil {
+		logger.Log.Error("Failed to insert API token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CreateAPITokenResponse{APIToken: token, Token: secret})
+}
+
+// listAPITokens writes all tokens (active, expired and revoked) acting as ownerID, newest first.
+func listAPITokens(c *gin.Context, ownerID string) {
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+apiTokenColumns+`
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, ownerID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch API tokens: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+	defer rows.Close()
+
+	tokens := []model.APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan API token: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+			return
+		}
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("Error iterating API tokens: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APITokenListResponse{Tokens: tokens})
+}
+
+// revokeAPIToken revokes one of ownerID's active tokens and writes 204, or 404 if there is none.
+func revokeAPIToken(c *gin.Context, ownerID string) {
+	tokenID := c.Param("tokenId")
+	if _, err := uuid.Parse(tokenID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE api_tokens SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, tokenID, ownerID)
+	if err != nil {
+		logger.Log.Error("Failed to revoke API token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateAPIToken issues a personal access token acting as the authenticated user.
+// The token secret is returned once in the 201 response and cannot be retrieved later.
+// Error responses: 400 (validation / no access to project_id), 500 (database error)
+func CreateAPIToken(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	issueAPIToken(c, user.RegistrationID, user)
+}
+
+// ListAPITokens returns the authenticated user's personal access tokens (without secrets).
+func ListAPITokens(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	listAPITokens(c, user.RegistrationID)
+}
+
+// RevokeAPIToken revokes one of the authenticated user's personal access tokens.
+// Error responses: 404 (not found / already revoked), 500 (database error)
+func RevokeAPIToken(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	revokeAPIToken(c, user.RegistrationID)
+}
+
+// CreateServiceAccount creates a registration for automation (CI jobs, QA tooling).
+// Service accounts have no Supabase login and authenticate with API tokens only; they belong
+// to the creating Admin's organisation and must be added to projects like any other user.
+// Error responses: 400 (validation / unknown or privileged role), 500 (database error)
+func CreateServiceAccount(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, &input)})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if input.Role == "" {
+		input.Role = model.DefaultRole
+	}
+	role, ok := model.NormalizeRole(input.Role)
+	if !ok || model.IsPrivilegedRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be one of: Developer, QA, Designer"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Service accounts need a unique email; the reserved .invalid domain can never receive mail
+	id := uuid.NewString()
+	email := "svc-" + id + "@service-accounts.invalid"
+
+	account, err := scanRegistration(db.Pool.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO registrations (id, full_name, email, organisation_id, role, is_service_account)
+			VALUES ($1, $2, $3, $4, $5, TRUE)
+			RETURNING id, full_name, email, organisation_id, role, supabase_user_id, is_service_account, created_at
+		)
+		SELECT `+registrationColumns+`
+		FROM inserted r
+		JOIN organisations o ON o.id = r.organisation_id
+	`, id, name, email, user.OrganisationID, role))
+	if err != nil {
+		logger.Log.Error("Failed to insert service account: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListServiceAccounts returns the service accounts of the Admin's organisation, oldest first.
+func ListServiceAccounts(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+registrationColumns+`
+		FROM registrations r
+		JOIN organisations o ON o.id = r.organisation_id
+		WHERE r.is_service_account AND r.organisation_id = $1
+		ORDER BY r.created_at
+	`, user.OrganisationID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch service accounts: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service accounts"})
+		return
+	}
+	defer rows.Close()
+
+	accounts := []model.Registration{}
+	for rows.Next() {
+		a, err := scanRegistration(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan service account: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service accounts"})
+			return
+		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("Error iterating service accounts: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ServiceAccountListResponse{ServiceAccounts: accounts})
+}
+
+// serviceAccountParam reads :userId and checks that it names a service account of the
+// user's organisation. On failure it writes the error response (404 or 500) and returns ok=false.
+func serviceAccountParam(c *gin.Context, user *middleware.UserContext) (string, bool) {
+	accountID := c.Param("userId")
+	if _, err := uuid.Parse(accountID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+		return "", false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var isServiceAccount bool
+	err := db.Pool.QueryRow(ctx,
+		`SELECT is_service_account FROM registrations WHERE id = $1 AND organisation_id = $2`,
+		accountID, user.OrganisationID,
+	).Scan(&isServiceAccount)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !isServiceAccount) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+		return "", false
+	}
+	if err != nil {
+		logger.Log.Error("Failed to fetch service account: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service account"})
+		return "", false
+	}
+	return accountID, true
+}
+
+// CreateServiceAccountToken issues an API key for a service account. The key is returned
+// once in the 201 response. Only accessible by users with the "Admin" role.
+// Error responses: 400 (validation / account not in project_id), 404 (not a service account), 500
+func CreateServiceAccountToken(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	accountID, ok := serviceAccountParam(c, user)
+	if !ok {
+		return
+	}
+	issueAPIToken(c, accountID, user)
+}
+
+// ListServiceAccountTokens returns a service account's API keys (without secrets).
+func ListServiceAccountTokens(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	accountID, ok := serviceAccountParam(c, user)
+	if !ok {
+		return
+	}
+	listAPITokens(c, accountID)
+}
+
+// RevokeServiceAccountToken revokes one of a service account's API keys.
+// Error responses: 404 (not a service account / token not found or already revoked), 500
+func RevokeServiceAccountToken(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	accountID, ok := serviceAccountParam(c, user)
+	if !ok {
+		return
+	}
+	revokeAPIToken(c, accountID)
+}
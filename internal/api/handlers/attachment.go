@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/Ankit1974/TaskDeskBackend/internal/storage"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// attachmentTransferTimeout bounds moving attachment content to or from the blob store.
+// Database operations keep the usual 5-second timeout.
+const attachmentTransferTimeout = 2 * time.Minute
+
+// attachmentColumns selects an attachment with its uploader's name.
+// Expects bug_attachments aliased as "ba" and registrations as "u"; keep in sync with scanAttachment.
+const attachmentColumns = `ba.id, ba.bug_id, ba.file_name, ba.content_type, ba.size_bytes, ba.sha256,
+	ba.uploaded_by, u.full_name, ba.created_at, ba.storage_key`
+
+// scanAttachment reads a single row selected with attachmentColumns.
+func scanAttachment(row pgx.Row) (model.BugAttachment, error) {
+	var a model.BugAttachment
+	err := row.Scan(
+		&a.ID, &a.BugID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.SHA256,
+		&a.UploadedBy, &a.UploadedByName, &a.CreatedAt, &a.StorageKey,
+	)
+	return a, err
+}
+
+// fetchAttachment returns an attachment of the bug by the :attachmentId parameter, or pgx.ErrNoRows.
+func fetchAttachment(ctx context.Context, c *gin.Context, bugID string) (model.BugAttachment, error) {
+	attachmentID := c.Param("attachmentId")
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		return model.BugAttachment{}, pgx.ErrNoRows
+	}
+	return scanAttachment(db.Pool.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM bug_attachments ba
+		JOIN registrations u ON u.id = ba.uploaded_by
+		WHERE ba.id = $1 AND ba.bug_id = $2
+	`, attachmentID, bugID))
+}
+
+// attachmentLimits returns the per-file and per-bug size limits in bytes.
+func attachmentLimits() (perFile, perBug int64) {
+	return int64(config.Cfg.AttachmentMaxFileMB) << 20, int64(config.Cfg.AttachmentMaxBugMB) << 20
+}
+
+// allowedAttachmentType reports whether a sniffed content type may be stored. Screenshots,
+// recordings, logs and common archives are accepted. SVG, HTML and other active content
+// is rejected, as it could run scripts if a browser ever rendered it inline.
+func allowedAttachmentType(contentType string) bool {
+	switch {
+	case contentType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(contentType, "image/"), strings.HasPrefix(contentType, "video/"):
+		return true
+	}
+	switch contentType {
+	case "text/plain", "text/csv", "application/json", "application/pdf",
+		"application/zip", "application/gzip", "application/x-tar":
+		return true
+	}
+	return false
+}
+
+// sniffAttachment detects the content type of an uploaded file from its first bytes and
+// rewinds the file. Parameters such as charset are dropped.
+func sniffAttachment(f multipart.File) (string, error) {
+	detected, err := mimetype.DetectReader(f)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(detected.String(), ";")
+	return strings.TrimSpace(contentType), nil
+}
+
+// sanitizeFileName keeps the base name of a client-supplied file name, without control
+// characters, truncated to 255 bytes.
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// attachmentUsageQuery sums the size of a bug's attachments.
+const attachmentUsageQuery = `SELECT COALESCE(SUM(size_bytes), 0) FROM bug_attachments WHERE bug_id = $1`
+
+// attachmentHistoryValue identifies an attachment in the bug's history.
+func attachmentHistoryValue(a model.BugAttachment) map[string]string {
+	return map[string]string{"id": a.ID, "file_name": a.FileName}
+}
+
+// deleteBlobs removes stored objects after a failed upload or a purge. Failures are only
+// logged: the objects are unreachable without their database rows.
+func deleteBlobs(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentTransferTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if err := storage.Default.Delete(ctx, key); err != nil {
+			logger.Log.Error("Failed to delete stored attachment " + key + ": " + err.Error())
+		}
+	}
+}
+
+// UploadBugAttachments attaches one or more files to a bug. Files are sent as
+// multipart/form-data in the "files" field (or "file" for a single file). Their content type
+// is sniffed from the content, and only images (except SVG), videos, plain text, CSV, JSON,
+// PDF and zip/gzip/tar archives are accepted. Each file is limited to ATTACHMENT_MAX_FILE_MB
+// and all attachments of a bug together to ATTACHMENT_MAX_BUG_MB. Requires the update bug
+// permission (any project role except viewer); the bug's updated_at is bumped.
+// Error responses: 400 (no files / malformed form), 403 (role not allowed), 404 (bug not found),
+// 409 (project archived), 413 (size limit), 415 (file type not allowed), 500 (storage or database error)
+func UploadBugAttachments(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	maxFile, maxBug := attachmentLimits()
+
+	// 5-second timeout for the authorization queries
+	authCtx, cancelAuth := context.WithTimeout(context.Background(), 5*time.Second)
+	bugID, _, ok := authorizeBug(c, authCtx, user, permUpdateBug)
+	cancelAuth()
+	if !ok {
+		return
+	}
+
+	// A single request can never add more than the per-bug quota (plus multipart overhead)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBug+1<<20)
+	if err := c.Request.ParseMultipartForm(8 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Upload exceeds the %d MB attachment limit per bug", config.Cfg.AttachmentMaxBugMB),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must be multipart/form-data with a \"files\" field"})
+		return
+	}
+	defer c.Request.MultipartForm.RemoveAll()
+
+	files := append(c.Request.MultipartForm.File["files"], c.Request.MultipartForm.File["file"]...)
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one file is required in the \"files\" field"})
+		return
+	}
+
+	// Validate every file before storing any of them
+	var uploadSize int64
+	contentTypes := make([]string, len(files))
+	for i, fh := range files {
+		name := sanitizeFileName(fh.Filename)
+		if fh.Size == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File %q is empty", name)})
+			return
+		}
+		if fh.Size > maxFile {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("File %q exceeds the %d MB limit per file", name, config.Cfg.AttachmentMaxFileMB),
+			})
+			return
+		}
+		uploadSize += fh.Size
+
+		f, err := fh.Open()
+		if err != nil {
+			logger.Log.Error("Failed to open uploaded file: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+		contentType, err := sniffAttachment(f)
+		f.Close()
+		if err != nil {
+			logger.Log.Error("Failed to detect attachment type: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+		if !allowedAttachmentType(contentType) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error": fmt.Sprintf("File %q has an unsupported type (%s)", name, contentType),
+			})
+			return
+		}
+		contentTypes[i] = contentType
+	}
+
+	// Fail fast on the quota; it is checked again under the bug lock before saving
+	var used int64
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err := db.Pool.QueryRow(ctx, attachmentUsageQuery, bugID).Scan(&used)
+	cancel()
+	if err != nil {
+		logger.Log.Error("Failed to sum attachment sizes: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+		return
+	}
+	if used+uploadSize > maxBug {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Upload exceeds the %d MB attachment limit per bug", config.Cfg.AttachmentMaxBugMB),
+		})
+		return
+	}
+
+	// Store the content first; the rows are only inserted once every file is stored
+	attachments := make([]model.BugAttachment, len(files))
+	var stored []string
+	saved := false
+	defer func() {
+		if !saved {
+			deleteBlobs(stored)
+		}
+	}()
+
+	putCtx, cancelPut := context.WithTimeout(context.Background(), attachmentTransferTimeout)
+	defer cancelPut()
+	for i, fh := range files {
+		id := uuid.NewString()
+		key := "bugs/" + bugID + "/" + id
+
+		f, err := fh.Open()
+		if err != nil {
+			logger.Log.Error("Failed to open uploaded file: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+		hash := sha256.New()
+		err = storage.Default.Put(putCtx, key, io.TeeReader(f, hash), fh.Size, contentTypes[i])
+		f.Close()
+		if err != nil {
+			logger.Log.Error("Failed to store attachment: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+		stored = append(stored, key)
+
+		attachments[i] = model.BugAttachment{
+			ID:          id,
+			BugID:       bugID,
+			FileName:    sanitizeFileName(fh.Filename),
+			ContentType: contentTypes[i],
+			SizeBytes:   fh.Size,
+			SHA256:      hex.EncodeToString(hash.Sum(nil)),
+			StorageKey:  key,
+		}
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// Lock the bug so that concurrent uploads cannot both pass the quota check
+	_, err = tx.Exec(ctx, `SELECT 1 FROM bugs WHERE id = $1 FOR UPDATE`, bugID)
+	if err == nil {
+		err = tx.QueryRow(ctx, attachmentUsageQuery, bugID).Scan(&used)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to sum attachment sizes: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+		return
+	}
+	if used+uploadSize > maxBug {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Upload exceeds the %d MB attachment limit per bug", config.Cfg.AttachmentMaxBugMB),
+		})
+		return
+	}
+
+	for i, a := range attachments {
+		attachments[i], err = scanAttachment(tx.QueryRow(ctx, `
+			WITH inserted AS (
+				INSERT INTO bug_attachments (id, bug_id, file_name, content_type, size_bytes, sha256, storage_key, uploaded_by)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				RETURNING *
+			)
+			SELECT `+attachmentColumns+`
+			FROM inserted ba
+			JOIN registrations u ON u.id = ba.uploaded_by
+		`, a.ID, a.BugID, a.FileName, a.ContentType, a.SizeBytes, a.SHA256, a.StorageKey, user.RegistrationID))
+		if err != nil {
+			logger.Log.Error("Failed to insert attachment: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+		change := bugChange{field: "attachment", new: attachmentHistoryValue(attachments[i])}
+		if err := recordBugChanges(ctx, tx, bugID, user.RegistrationID, []bugChange{change}); err != nil {
+			logger.Log.Error("Failed to record bug history: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE bugs SET updated_at = NOW() WHERE id = $1`, bugID); err != nil {
+		logger.Log.Error("Failed to update bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit attachments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachments"})
+		return
+	}
+	saved = true
+
+	c.JSON(http.StatusCreated, model.BugAttachmentListResponse{
+		Attachments: attachments,
+		TotalCount:  len(attachments),
+		TotalBytes:  used + uploadSize,
+		LimitBytes:  maxBug,
+	})
+}
+
+// ListBugAttachments returns all attachments of a bug, oldest first, with the bug's quota
+// usage. Any project role can list them.
+func ListBugAttachments(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permViewProject)
+	if !ok {
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM bug_attachments ba
+		JOIN registrations u ON u.id = ba.uploaded_by
+		WHERE ba.bug_id = $1
+		ORDER BY ba.created_at, ba.id
+	`, bugID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch attachments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+	attachments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BugAttachment, error) {
+		return scanAttachment(row)
+	})
+	if err != nil {
+		logger.Log.Error("Failed to scan attachments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	var totalBytes int64
+	for _, a := range attachments {
+		totalBytes += a.SizeBytes
+	}
+	_, maxBug := attachmentLimits()
+
+	c.JSON(http.StatusOK, model.BugAttachmentListResponse{
+		Attachments: attachments,
+		TotalCount:  len(attachments),
+		TotalBytes:  totalBytes,
+		LimitBytes:  maxBug,
+	})
+}
+
+// DownloadBugAttachment streams an attachment's content. Access is checked exactly like
+// viewing the bug: any project role can download, other users get 404. The file is always
+// served as a download (Content-Disposition: attachment) with content sniffing disabled.
+func DownloadBugAttachment(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permViewProject)
+	if !ok {
+		return
+	}
+
+	attachment, err := fetchAttachment(ctx, c, bugID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch attachment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download attachment"})
+		return
+	}
+
+	getCtx, cancelGet := context.WithTimeout(context.Background(), attachmentTransferTimeout)
+	defer cancelGet()
+	content, err := storage.Default.Get(getCtx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logger.Log.Error("Stored content missing for attachment " + attachment.ID)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment content not found"})
+			return
+		}
+		logger.Log.Error("Failed to read attachment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download attachment"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":     mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+		"Cache-Control":           "private, no-store",
+	})
+}
+
+// DeleteBugAttachment removes an attachment. The uploader can delete their own attachments;
+// project owners and maintainers can delete any. The row is deleted first, so a failure to
+// remove the stored content only leaves an unreachable object behind (and is logged).
+// Error responses: 403 (role not allowed), 404 (not found), 409 (project archived), 500 (database error)
+func DeleteBugAttachment(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, access, ok := authorizeBug(c, ctx, user, permUpdateBug)
+	if !ok {
+		return
+	}
+
+	attachment, err := fetchAttachment(ctx, c, bugID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch attachment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	if attachment.UploadedBy != user.RegistrationID && !access.can(permDeleteAttachments) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your project role (" + access.Role + ") does not allow you to " + permDeleteAttachments.action})
+		return
+	}
+
+	// The row and its history entry are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	tag, err := tx.Exec(ctx, `DELETE FROM bug_attachments WHERE id = $1`, attachment.ID)
+	if err != nil {
+		logger.Log.Error("Failed to delete attachment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	change := bugChange{field: "attachment", old: attachmentHistoryValue(attachment)}
+	if err := recordBugChanges(ctx, tx, bugID, user.RegistrationID, []bugChange{change}); err != nil {
+		logger.Log.Error("Failed to record bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit attachment deletion: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+
+	deleteBlobs([]string{attachment.StorageKey})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// projectPermission is an action inside a project that is granted per project role.
+type projectPermission struct {
+	action string          // Completes "... does not allow you to <action>"
+	roles  map[string]bool // Project roles that hold the permission
+	write  bool            // Write permissions are denied while the project is archived
+}
+
+// roleSet builds the role lookup of a projectPermission.
+func roleSet(roles ...string) map[string]bool {
+	set := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		set[r] = true
+	}
+	return set
+}
+
+/*
+Project permissions by role:
+
+	                     owner  maintainer  developer  qa  viewer
+	view project           ✓        ✓           ✓       ✓     ✓
+	edit project           ✓        ✓
+	archive/restore/purge  ✓        ✓
+	manage members         ✓        ✓
+	list/revoke invites    ✓        ✓
+	create bug             ✓        ✓           ✓       ✓
+	update bug             ✓        ✓           ✓       ✓
+	assign bug             ✓        ✓                   ✓
+	close/reopen bug       ✓        ✓                   ✓
+	comment on bugs        ✓        ✓           ✓       ✓
+	moderate comments      ✓        ✓
+	delete any attachment  ✓        ✓
+	manage labels          ✓        ✓
+
+"update bug" is the baseline for status changes, attachment uploads, labels and bug links;
+canTransitionBug refines it per transition.
+*/
+var (
+	permViewProject = projectPermission{
+		action: "view this project",
+		roles: roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleDeveloper,
+			model.ProjectRoleQA, model.ProjectRoleViewer),
+	}
+	permEditProject = projectPermission{
+		action: "edit this project",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+		write:  true,
+	}
+	permArchiveProject = projectPermission{ // Not a write: restore must work on archived projects
+		action: "archive, restore or purge this project",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+	}
+	permManageMembers = projectPermission{
+		action: "manage members",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+		write:  true,
+	}
+	permManageInvitations = projectPermission{ // Not a write: pending invitations can be reviewed and revoked while archived
+		action: "manage invitations",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+	}
+	permCreateBug = projectPermission{
+		action: "create bugs",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleDeveloper, model.ProjectRoleQA),
+		write:  true,
+	}
+	permUpdateBug = projectPermission{
+		action: "update bugs",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleDeveloper, model.ProjectRoleQA),
+		write:  true,
+	}
+	permAssignBug = projectPermission{
+		action: "assign bugs",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleQA),
+		write:  true,
+	}
+	permCloseBug = projectPermission{
+		action: "close or reopen bugs",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleQA),
+		write:  true,
+	}
+	permCommentBug = projectPermission{
+		action: "comment on bugs",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer, model.ProjectRoleDeveloper, model.ProjectRoleQA),
+		write:  true,
+	}
+	permModerateComments = projectPermission{ // Delete other users' comments
+		action: "delete other users' comments",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+		write:  true,
+	}
+	permDeleteAttachments = projectPermission{ // Delete other users' attachments
+		action: "delete other users' attachments",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+		write:  true,
+	}
+	permManageLabels = projectPermission{ // Edit the label catalog
+		action: "manage labels",
+		roles:  roleSet(model.ProjectRoleOwner, model.ProjectRoleMaintainer),
+		write:  true,
+	}
+)
+
+// projectAccess is a user's relationship with a project.
+type projectAccess struct {
+	Role     string // Project role, or "" when the user has no access
+	Archived bool   // The project is archived (read-only)
+}
+
+// can reports whether the access grants the permission, including the archive check.
+func (a projectAccess) can(p projectPermission) bool {
+	return p.roles[a.Role] && !(p.write && a.Archived)
+}
+
+// loadProjectAccess returns the user's project role: "owner" for the creator, the
+// project_members role for members, and "" for everyone else. Projects outside the
+// organisation are treated as unknown, whatever the membership.
+func loadProjectAccess(ctx context.Context, projectID, userID, organisationID string) (projectAccess, error) {
+	var access projectAccess
+	if _, err := uuid.Parse(projectID); err != nil {
+		return access, nil
+	}
+
+	err := db.Pool.QueryRow(ctx, `
+		SELECT CASE WHEN p.created_by = $2 THEN 'owner' ELSE COALESCE(m.role, '') END,
+		       p.archived_at IS NOT NULL
+		FROM projects p
+		LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+		WHERE p.id = $1 AND p.organisation_id = $3
+	`, projectID, userID, organisationID).Scan(&access.Role, &access.Archived)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return projectAccess{}, nil
+	}
+	return access, err
+}
+
+/*
+authorizeProject is the single access check for project-scoped handlers. It loads the
+user's project role (within their organisation) and writes the error response unless the role holds the permission:
+  - 404 if the user cannot see the project (so its existence is not revealed)
+  - 403 if the role does not hold the permission
+  - 409 if the permission writes and the project is archived
+  - 500 if the lookup fails
+
+It returns the access so handlers can make finer decisions (see canTransitionBug).
+*/
+func authorizeProject(c *gin.Context, ctx context.Context, projectID string, user *middleware.UserContext, perm projectPermission) (projectAccess, bool) {
+	access, err := loadProjectAccess(ctx, projectID, user.RegistrationID, user.OrganisationID)
+	if err != nil {
+		logger.Log.Error("Failed to check project access: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify project access"})
+		return access, false
+	}
+	if access.Role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return access, false
+	}
+	if !perm.roles[access.Role] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your project role (" + access.Role + ") does not allow you to " + perm.action})
+		return access, false
+	}
+	if perm.write && access.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project is archived. Restore it first"})
+		return access, false
+	}
+	return access, true
+}
@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
@@ -11,12 +15,68 @@ import (
 	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
 	"github.com/Ankit1974/TaskDeskBackend/internal/model"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
+// bugColumns is the column list shared by every query that returns full bug rows.
+// Keep it in sync with scanBug.
+const bugColumns = `id, project_id, bug_number, title, priority, description, steps, version, platform, status, resolution, created_by, assigned_to, created_at, updated_at`
+
+// bugScanDest returns the scan destinations for bugColumns, in order.
+// Queries that select extra columns after bugColumns append their own destinations.
+func bugScanDest(b *model.Bug) []any {
+	return []any{
+		&b.ID, &b.ProjectID, &b.BugNumber, &b.Title, &b.Priority,
+		&b.Description, &b.Steps, &b.Version, &b.Platform,
+		&b.Status, &b.Resolution, &b.CreatedBy, &b.AssignedTo,
+		&b.CreatedAt, &b.UpdatedAt,
+	}
+}
+
+// scanBug reads a single row selected with bugColumns into a model.Bug.
+func scanBug(row pgx.Row) (model.Bug, error) {
+	var b model.Bug
+	err := row.Scan(bugScanDest(&b)...)
+	if b.Steps == nil {
+		b.Steps = []string{} // Ensure JSON serializes as [] instead of null
+	}
+	return b, err
+}
+
+// errProjectArchived is returned when a write targets an archived (read-only) project.
+var errProjectArchived = errors.New("project is archived")
+
+// allocateBugNumbers reserves count consecutive bug numbers for a project and returns the
+// project's bug prefix and the first reserved number. It must run inside the transaction
+// that inserts the bugs: the UPDATE locks the project row until that transaction ends.
+// Returns errProjectArchived if the project is archived.
+func allocateBugNumbers(ctx context.Context, tx pgx.Tx, projectID string, count int) (string, int, error) {
+	var prefix string
+	var last int
+	err := tx.QueryRow(ctx, `
+		UPDATE projects SET bug_seq = bug_seq + $2
+		WHERE id = $1 AND archived_at IS NULL
+		RETURNING bug_prefix, bug_seq
+	`, projectID, count).Scan(&prefix, &last)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", 0, errProjectArchived
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return prefix, last - count + 1, nil
+}
+
 // CreateBugs handles batch bug creation for a specific project.
-// Only the project creator or assigned members can create bugs.
-// Accepts 1–20 bugs per request.
+// Requires the create bug permission; bugs with an assignee also need the assign permission.
+// Accepts 1–20 bugs per request. The batch is all-or-nothing (422 with per-bug results
+// if any entry is invalid) unless ?partial=true is set, in which case the valid bugs are
+// created and the response lists the outcome of every entry (207 if some were rejected).
+// Labels must exist in the project's catalog. @mentions of project members in descriptions
+// are recorded (see recordMentions), and the fields set on each bug start its change history
+// (see GetBugHistory).
 func CreateBugs(c *gin.Context) {
 	// Get the authenticated user (set by AuthMiddleware)
 	user := middleware.GetUser(c)
@@ -35,55 +95,87 @@ func CreateBugs(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Verify the user has access to this project (creator or member)
-	accessQuery := `
-		SELECT EXISTS(
-			SELECT 1 FROM projects WHERE id = $1 AND created_by = $2
-			UNION
-			SELECT 1 FROM project_members WHERE project_id = $1 AND user_id = $2
-		)
-	`
-	var hasAccess bool
-	err := db.Pool.QueryRow(ctx, accessQuery, projectID, user.RegistrationID).Scan(&hasAccess)
-	if err != nil {
-		logger.Log.Error("Failed to check project access: " + err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify project access"})
-		return
-	}
-	if !hasAccess {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this project"})
+	access, ok := authorizeProject(c, ctx, projectID, user, permCreateBug)
+	if !ok {
 		return
 	}
 
-	// Bind and validate the JSON request body
+	// ?partial=true commits the valid bugs and reports the rejected ones;
+	// by default the whole batch is rejected if any single bug is invalid.
+	partial, _ := strconv.ParseBool(c.DefaultQuery("partial", "false"))
+
+	// Bind and validate the JSON request body (the array itself; entries are checked below)
 	var input model.CreateBugsRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get the current max bug number for this project to generate sequential IDs
-	var currentMax int
-	err = db.Pool.QueryRow(ctx,
-		`SELECT COALESCE(MAX(CAST(SUBSTRING(bug_number FROM 5) AS INTEGER)), 0) FROM bugs WHERE project_id = $1`,
-		projectID,
-	).Scan(&currentMax)
+	// Validate every entry up front so that problems are reported per bug
+	// instead of surfacing as a database error halfway through the batch.
+	itemErrors, err := validateBugItems(ctx, input.Bugs, access.can(permAssignBug), projectID)
 	if err != nil {
-		logger.Log.Error("Failed to get max bug number: " + err.Error())
+		logger.Log.Error("Failed to validate bugs: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
 		return
 	}
 
-	// Batch insert all bugs using pgx.Batch for efficiency
+	results := make([]model.BugItemResult, len(input.Bugs))
+	validIndexes := make([]int, 0, len(input.Bugs))
+	for i, itemErr := range itemErrors {
+		results[i].Index = i
+		if itemErr != "" {
+			results[i].Status = "rejected"
+			results[i].Error = itemErr
+			continue
+		}
+		results[i].Status = "valid"
+		validIndexes = append(validIndexes, i)
+	}
+
+	rejected := len(input.Bugs) - len(validIndexes)
+	if len(validIndexes) == 0 || (rejected > 0 && !partial) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   fmt.Sprintf("%d of %d bugs are invalid; no bugs were created", rejected, len(input.Bugs)),
+			"results": results,
+		})
+		return
+	}
+
+	// The number allocation and the inserts share one transaction: the projects row lock
+	// taken by allocateBugNumbers serialises concurrent requests for the same project, and
+	// a rollback also rolls back the counter, so numbers stay distinct and gap-free.
+	// Any failure from here on rolls back every bug of the request.
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	prefix, firstNumber, err := allocateBugNumbers(ctx, tx, projectID, len(validIndexes))
+	if errors.Is(err, errProjectArchived) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project is archived. Restore it before filing bugs"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to allocate bug numbers: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+
+	// Batch insert all valid bugs using pgx.Batch for efficiency
 	batch := &pgx.Batch{}
 	insertQuery := `
 		INSERT INTO bugs (project_id, bug_number, title, priority, description, steps, version, platform, created_by, assigned_to)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, project_id, bug_number, title, priority, description, steps, version, platform, status, created_by, assigned_to, created_at, updated_at
+		RETURNING ` + bugColumns + `
 	`
 
-	for i, bug := range input.Bugs {
-		bugNumber := fmt.Sprintf("BUG-%d", currentMax+i+1)
+	for n, idx := range validIndexes {
+		bug := input.Bugs[idx]
+		bugNumber := fmt.Sprintf("%s-%d", prefix, firstNumber+n)
 
 		// Convert optional fields: empty string → nil for SQL NULL
 		var description *string
@@ -118,33 +210,543 @@ func CreateBugs(c *gin.Context) {
 		)
 	}
 
-	// Execute the batch
-	br := db.Pool.SendBatch(ctx, batch)
-	defer br.Close()
+	// Execute the batch inside the transaction
+	br := tx.SendBatch(ctx, batch)
 
 	// Collect the returned bugs
-	bugs := make([]model.Bug, 0, len(input.Bugs))
-	for range input.Bugs {
-		var b model.Bug
-		err := br.QueryRow().Scan(
-			&b.ID, &b.ProjectID, &b.BugNumber, &b.Title, &b.Priority,
-			&b.Description, &b.Steps, &b.Version, &b.Platform,
-			&b.Status, &b.CreatedBy, &b.AssignedTo,
-			&b.CreatedAt, &b.UpdatedAt,
-		)
+	bugs := make([]model.Bug, 0, len(validIndexes))
+	for _, idx := range validIndexes {
+		b, err := scanBug(br.QueryRow())
 		if err != nil {
+			br.Close()
 			logger.Log.Error("Failed to insert bug: " + err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
 			return
 		}
-		if b.Steps == nil {
-			b.Steps = []string{}
-		}
 		bugs = append(bugs, b)
+		results[idx].Status = "created"
+		results[idx].Bug = &bugs[len(bugs)-1]
+	}
+
+	// The batch must be closed before the transaction can be used again
+	if err := br.Close(); err != nil {
+		logger.Log.Error("Failed to insert bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+
+	// Mentions in descriptions notify the mentioned members
+	candidates, err := loadMentionCandidates(ctx, tx, projectID)
+	if err != nil {
+		logger.Log.Error("Failed to load project members: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+	for i := range bugs {
+		if bugs[i].Description == nil {
+			continue
+		}
+		bugs[i].Mentions, err = recordMentions(ctx, tx, candidates, bugs[i].ID, nil, user.RegistrationID, *bugs[i].Description)
+		if err != nil {
+			logger.Log.Error("Failed to record mentions: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+			return
+		}
+	}
+
+	// Labels given at creation are applied from the project's catalog
+	for n, idx := range validIndexes {
+		if len(input.Bugs[idx].Labels) == 0 {
+			continue
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO bug_labels (bug_id, label_id, added_by)
+			SELECT $1, id, $4 FROM labels WHERE project_id = $2 AND LOWER(name) = ANY($3)
+		`, bugs[n].ID, projectID, lowerAll(input.Bugs[idx].Labels), user.RegistrationID)
+		if err != nil {
+			logger.Log.Error("Failed to add bug labels: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+			return
+		}
+	}
+	if err := attachBugLabels(ctx, tx, bugs); err != nil {
+		logger.Log.Error("Failed to fetch bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+
+	// Initial assignees start the assignment history of their bugs
+	bugIDs := make([]string, len(bugs))
+	for i := range bugs {
+		bugIDs[i] = bugs[i].ID
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO bug_assignments (bug_id, from_assignee, to_assignee, assigned_by, assigned_at)
+		SELECT id, NULL, assigned_to, created_by, created_at
+		FROM bugs
+		WHERE id = ANY($1::UUID[]) AND assigned_to IS NOT NULL
+	`, bugIDs)
+	if err != nil {
+		logger.Log.Error("Failed to record bug assignments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
 	}
 
-	c.JSON(http.StatusCreated, model.CreateBugsResponse{
+	// Every field set at creation starts the bug's change history, and each bug
+	// appears in the project's activity feed
+	for i := range bugs {
+		if err := recordBugChanges(ctx, tx, bugs[i].ID, user.RegistrationID, diffBug(nil, bugs[i])); err != nil {
+			logger.Log.Error("Failed to record bug history: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+			return
+		}
+		err := recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityBugCreated, &bugs[i].ID, gin.H{
+			"bug_number": bugs[i].BugNumber, "title": bugs[i].Title,
+		})
+		if err != nil {
+			logger.Log.Error("Failed to record activity: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bugs"})
+		return
+	}
+
+	response := model.CreateBugsResponse{
 		Bugs:  bugs,
 		Count: len(bugs),
+	}
+	if !partial {
+		c.JSON(http.StatusCreated, response)
+		return
+	}
+
+	// Partial batches report every entry; 207 signals that some were rejected
+	response.Results = results
+	status := http.StatusCreated
+	if rejected > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// validateBugItems checks each bug of a batch against its binding rules, verifies that
+// assignees are members of the project (the owner included) and that labels exist in the
+// project's catalog. Bugs with an assignee are rejected unless canAssign is set. Label names
+// are normalized in place. It returns one message per bug ("" when valid).
+func validateBugItems(ctx context.Context, items []model.CreateBugRequest, canAssign bool, projectID string) ([]string, error) {
+	itemErrors := make([]string, len(items))
+	assignees := []string{}
+	labels := []string{}
+	for i := range items {
+		if err := binding.Validator.ValidateStruct(&items[i]); err != nil {
+			itemErrors[i] = validationMessage(err, items[i])
+			continue
+		}
+		if items[i].AssignedTo != "" && !canAssign {
+			itemErrors[i] = "Your project role does not allow you to assign bugs"
+			continue
+		}
+		names, msg := normalizeLabelNames(items[i].Labels)
+		if msg != "" {
+			itemErrors[i] = msg
+			continue
+		}
+		items[i].Labels = names
+		labels = append(labels, names...)
+		if items[i].AssignedTo != "" {
+			assignees = append(assignees, items[i].AssignedTo)
+		}
+	}
+
+	// Look up all labels in one round trip
+	if len(labels) > 0 {
+		unknown, err := unknownLabels(ctx, projectID, labels)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			if itemErrors[i] != "" {
+				continue
+			}
+			for _, name := range item.Labels {
+				if slices.Contains(unknown, name) {
+					itemErrors[i] = fmt.Sprintf("unknown label %q", name)
+					break
+				}
+			}
+		}
+	}
+	if len(assignees) == 0 {
+		return itemErrors, nil
+	}
+
+	// Look up all assignees in one round trip
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.created_by::TEXT FROM projects p WHERE p.id = $2 AND p.created_by = ANY($1::UUID[])
+		UNION
+		SELECT m.user_id::TEXT FROM project_members m WHERE m.project_id = $2 AND m.user_id = ANY($1::UUID[])
+	`, assignees, projectID)
+	if err != nil {
+		return nil, err
+	}
+	known, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	isMember := make(map[string]bool, len(known))
+	for _, id := range known {
+		isMember[id] = true
+	}
+
+	for i, item := range items {
+		if itemErrors[i] == "" && item.AssignedTo != "" && !isMember[strings.ToLower(item.AssignedTo)] {
+			itemErrors[i] = "assigned_to is not a member of this project"
+		}
+	}
+	return itemErrors, nil
+}
+
+// bugSortColumns maps the allowed ?sort= values to their SQL ORDER BY expressions.
+// Priority is ranked by severity so that "desc" lists critical bugs first.
+var bugSortColumns = map[string]string{
+	"created_at": "b.created_at",
+	"updated_at": "b.updated_at",
+	"priority":   "CASE b.priority WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END",
+}
+
+// optionalParam returns a pointer to the query parameter value, or nil when it is absent.
+// nil is passed to SQL as NULL so the matching "$n IS NULL OR ..." filter is skipped.
+func optionalParam(c *gin.Context, key string) *string {
+	value := strings.TrimSpace(c.Query(key))
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// ListBugs returns the bugs of a project. Any project role can list them; other users get 404.
+// Supports optional query parameters:
+//   - status:      filter by bug status (open, in_progress, resolved, closed)
+//   - priority:    filter by priority (critical, high, medium, low)
+//   - assigned_to: filter by assignee registration UUID
+//   - created_by:  filter by reporter registration UUID
+//   - platform:    filter by platform (case-insensitive exact match)
+//   - version:     filter by version (exact match)
+//   - labels:      comma-separated label names (case-insensitive)
+//   - label_match: any (default; bugs with at least one of the labels) or all (bugs with every label)
+//   - sort:        created_at (default), updated_at or priority
+//   - order:       desc (default) or asc
+//   - page:        page number (default: 1)
+//   - limit:       items per page (default: 10, max: 50)
+func ListBugs(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Parse and validate pagination query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	// Validate enum filters against the values allowed by the bugs table constraints
+	status := optionalParam(c, "status")
+	if status != nil {
+		validStatuses := map[string]bool{
+			"open": true, "in_progress": true, "resolved": true, "closed": true,
+		}
+		if !validStatuses[*status] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid status. Must be one of: open, in_progress, resolved, closed",
+			})
+			return
+		}
+	}
+
+	priority := optionalParam(c, "priority")
+	if priority != nil {
+		validPriorities := map[string]bool{
+			"critical": true, "high": true, "medium": true, "low": true,
+		}
+		if !validPriorities[*priority] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid priority. Must be one of: critical, high, medium, low",
+			})
+			return
+		}
+	}
+
+	// User filters must be UUIDs, otherwise PostgreSQL rejects the cast with a 500
+	assignedTo := optionalParam(c, "assigned_to")
+	if assignedTo != nil {
+		if _, err := uuid.Parse(*assignedTo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assigned_to. Must be a UUID"})
+			return
+		}
+	}
+
+	createdBy := optionalParam(c, "created_by")
+	if createdBy != nil {
+		if _, err := uuid.Parse(*createdBy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_by. Must be a UUID"})
+			return
+		}
+	}
+
+	platform := optionalParam(c, "platform")
+	version := optionalParam(c, "version")
+
+	// Label names are compared in lowercase; nil skips the filter
+	var labels []string
+	if raw := optionalParam(c, "labels"); raw != nil {
+		for _, name := range strings.Split(*raw, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && !slices.Contains(labels, name) {
+				labels = append(labels, name)
+			}
+		}
+	}
+	labelMatch := strings.ToLower(c.DefaultQuery("label_match", "any"))
+	if labelMatch != "any" && labelMatch != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid label_match. Must be one of: any, all"})
+		return
+	}
+
+	// Sorting: the column comes from a whitelist, never from raw user input
+	sortColumn, ok := bugSortColumns[c.DefaultQuery("sort", "created_at")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid sort. Must be one of: created_at, updated_at, priority",
+		})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order. Must be one of: asc, desc"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
+
+	filters := `
+		WHERE b.project_id = $1
+		AND ($2::VARCHAR IS NULL OR b.status = $2)
+		AND ($3::VARCHAR IS NULL OR b.priority = $3)
+		AND ($4::UUID IS NULL OR b.assigned_to = $4)
+		AND ($5::UUID IS NULL OR b.created_by = $5)
+		AND ($6::VARCHAR IS NULL OR LOWER(b.platform) = LOWER($6))
+		AND ($7::VARCHAR IS NULL OR b.version = $7)
+		AND ($8::TEXT[] IS NULL OR (
+			SELECT COUNT(*) FROM bug_labels bl JOIN labels l ON l.id = bl.label_id
+			WHERE bl.bug_id = b.id AND LOWER(l.name) = ANY($8)
+		) >= CASE WHEN $9::BOOLEAN THEN cardinality($8) ELSE 1 END)
+	`
+	args := []any{projectID, status, priority, assignedTo, createdBy, platform, version, labels, labelMatch == "all"}
+
+	// Count total matching bugs (for pagination metadata)
+	var totalCount int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM bugs b`+filters, args...).Scan(&totalCount)
+	if err != nil {
+		logger.Log.Error("Failed to count bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+		return
+	}
+
+	// Fetch the requested page; created_at and id break ties so pages are stable
+	dataQuery := `SELECT ` + prefixColumns("b", bugColumns) + ` FROM bugs b` + filters +
+		fmt.Sprintf(` ORDER BY %s %s, b.created_at DESC, b.id LIMIT $10 OFFSET $11`, sortColumn, order)
+
+	rows, err := db.Pool.Query(ctx, dataQuery, append(args, limit, offset)...)
+	if err != nil {
+		logger.Log.Error("Failed to query bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows into Bug structs (empty slice, not nil, for clean JSON [])
+	bugs := []model.Bug{}
+	for rows.Next() {
+		b, err := scanBug(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan bug row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+			return
+		}
+		bugs = append(bugs, b)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+		return
+	}
+
+	if err := attachBugLabels(ctx, db.Pool, bugs); err != nil {
+		logger.Log.Error("Failed to fetch bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BugListResponse{
+		Bugs:       bugs,
+		TotalCount: totalCount,
+		Page:       page,
+		Limit:      limit,
 	})
 }
+
+// prefixColumns qualifies each column of a comma-separated list with a table alias,
+// e.g. prefixColumns("b", "id, title") returns "b.id, b.title".
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, col := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(col)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bugRefArgs splits a bug reference from the URL into the two lookup parameters used by
+// the "(b.id = $n OR UPPER(b.bug_number) = UPPER($m))" filter. A reference that parses as a
+// UUID matches bugs.id; anything else (e.g. "BUG-42") matches bug_number case-insensitively.
+func bugRefArgs(bugRef string) (id *string, number *string) {
+	if _, err := uuid.Parse(bugRef); err == nil {
+		return &bugRef, nil
+	}
+	return nil, &bugRef
+}
+
+/*
+authorizeBug is the access check for handlers of a bug's sub-resources (comments, ...).
+It reads the :id and :bugRef parameters, checks the permission with authorizeProject and
+resolves the bug reference to the bug's UUID. It writes the error response (400, 404 for
+unknown bugs, or the authorizeProject errors) and returns false on failure.
+*/
+func authorizeBug(c *gin.Context, ctx context.Context, user *middleware.UserContext, perm projectPermission) (string, projectAccess, bool) {
+	projectID := c.Param("id")
+	bugRef := strings.TrimSpace(c.Param("bugRef"))
+	if projectID == "" || bugRef == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID and bug reference are required"})
+		return "", projectAccess{}, false
+	}
+
+	access, ok := authorizeProject(c, ctx, projectID, user, perm)
+	if !ok {
+		return "", access, false
+	}
+
+	var id string
+	bugID, bugNumber := bugRefArgs(bugRef)
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id FROM bugs
+		WHERE project_id = $1
+		AND (id = $2::UUID OR UPPER(bug_number) = UPPER($3))
+	`, projectID, bugID, bugNumber).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return "", access, false
+		}
+		logger.Log.Error("Failed to fetch bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug"})
+		return "", access, false
+	}
+	return id, access, true
+}
+
+// GetBug returns a single bug with the reporter's and assignee's names, its labels and its links
+// to other bugs (see loadBugLinks).
+// The bug can be referenced by its UUID or by its bug_number (e.g. "BUG-42").
+// Any project role can view it; other users get 404 so that the bug's existence is not revealed.
+func GetBug(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	bugRef := strings.TrimSpace(c.Param("bugRef"))
+	if projectID == "" || bugRef == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID and bug reference are required"})
+		return
+	}
+	bugID, bugNumber := bugRefArgs(bugRef)
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
+
+	query := `
+		SELECT ` + prefixColumns("b", bugColumns) + `,
+		       reporter.full_name, assignee.full_name
+		FROM bugs b
+		JOIN registrations reporter ON reporter.id = b.created_by
+		LEFT JOIN registrations assignee ON assignee.id = b.assigned_to
+		WHERE b.project_id = $1
+		AND (b.id = $2::UUID OR UPPER(b.bug_number) = UPPER($3))
+	`
+
+	var detail model.BugDetail
+	dest := append(bugScanDest(&detail.Bug), &detail.ReporterName, &detail.AssigneeName)
+	err := db.Pool.QueryRow(ctx, query, projectID, bugID, bugNumber).Scan(dest...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug"})
+		return
+	}
+	if detail.Steps == nil {
+		detail.Steps = []string{}
+	}
+
+	labels, err := loadBugLabels(ctx, db.Pool, []string{detail.ID})
+	if err != nil {
+		logger.Log.Error("Failed to fetch bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug"})
+		return
+	}
+	detail.Labels = labels[detail.ID]
+
+	detail.Links, err = loadBugLinks(ctx, db.Pool, detail.ID, user)
+	if err != nil {
+		logger.Log.Error("Failed to fetch bug links: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug"})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateBugAssignee assigns, reassigns or unassigns (assigned_to null) a bug. The new
+// assignee must be a member of the project (the owner included). Requires the assign
+// permission (owner, maintainer or QA). Every change is recorded in bug_assignments with
+// the acting user, the time and the optional note, and in the bug's history.
+// Error responses: 400 (validation), 403 (project role not allowed), 404 (not found / no access),
+// 409 (assignee unchanged, bug closed or project archived), 422 (assignee not a project member),
+// 500 (database error)
+func UpdateBugAssignee(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	bugRef := strings.TrimSpace(c.Param("bugRef"))
+	if projectID == "" || bugRef == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID and bug reference are required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateBugAssigneeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	if input.AssignedTo != nil {
+		normalized := strings.ToLower(*input.AssignedTo)
+		input.AssignedTo = &normalized
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permAssignBug); !ok {
+		return
+	}
+
+	// The read, the update and the history insert must see the same row state,
+	// so everything runs in one transaction with the bug row locked.
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// Lock the bug for the rest of the transaction
+	bugID, bugNumber := bugRefArgs(bugRef)
+	bug, err := scanBug(tx.QueryRow(ctx, `
+		SELECT `+bugColumns+`
+		FROM bugs
+		WHERE project_id = $1
+		AND (id = $2::UUID OR UPPER(bug_number) = UPPER($3))
+		FOR UPDATE
+	`, projectID, bugID, bugNumber))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+
+	if bug.Status == "closed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Closed bugs cannot be reassigned. Reopen the bug first"})
+		return
+	}
+	if (bug.AssignedTo == nil && input.AssignedTo == nil) ||
+		(bug.AssignedTo != nil && input.AssignedTo != nil && *bug.AssignedTo == *input.AssignedTo) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bug already has this assignee"})
+		return
+	}
+
+	if input.AssignedTo != nil {
+		var isMember bool
+		err = tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND created_by = $2)
+			    OR EXISTS (SELECT 1 FROM project_members WHERE project_id = $1 AND user_id = $2)
+		`, projectID, *input.AssignedTo).Scan(&isMember)
+		if err != nil {
+			logger.Log.Error("Failed to check project membership: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+			return
+		}
+		if !isMember {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "assigned_to is not a member of this project"})
+			return
+		}
+	}
+
+	updated, err := scanBug(tx.QueryRow(ctx, `
+		UPDATE bugs SET assigned_to = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+bugColumns,
+		input.AssignedTo, bug.ID,
+	))
+	if err != nil {
+		logger.Log.Error("Failed to update bug assignee: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+
+	// Record who made the change, when, and why
+	var note *string
+	if trimmed := strings.TrimSpace(input.Note); trimmed != "" {
+		note = &trimmed
+	}
+	var change model.BugAssignment
+	err = tx.QueryRow(ctx, `
+		INSERT INTO bug_assignments (bug_id, from_assignee, to_assignee, note, assigned_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, bug_id, from_assignee, to_assignee, note, assigned_by, assigned_at
+	`, bug.ID, bug.AssignedTo, input.AssignedTo, note, user.RegistrationID).Scan(
+		&change.ID, &change.BugID, &change.FromAssignee, &change.ToAssignee,
+		&change.Note, &change.AssignedBy, &change.AssignedAt,
+	)
+	if err != nil {
+		logger.Log.Error("Failed to record bug assignment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+	if err := recordBugChanges(ctx, tx, bug.ID, user.RegistrationID, diffBug(&bug, updated)); err != nil {
+		logger.Log.Error("Failed to record bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityBugAssigned, &bug.ID, gin.H{
+		"bug_number": bug.BugNumber, "from": bug.AssignedTo, "to": input.AssignedTo,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug assignment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignee"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.UpdateBugAssigneeResponse{
+		Bug:    updated,
+		Change: change,
+	})
+}
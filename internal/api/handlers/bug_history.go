@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// bugChange is one field change to append to bug_history. Values are encoded as JSON;
+// nil, "" and empty lists are all stored as NULL (unset).
+type bugChange struct {
+	field    string
+	old, new any
+}
+
+// trackedBugFields are the bug fields whose changes are recorded, in display order.
+// A new bug field becomes audited by adding it here.
+var trackedBugFields = []struct {
+	name  string
+	value func(model.Bug) any
+}{
+	{"title", func(b model.Bug) any { return b.Title }},
+	{"priority", func(b model.Bug) any { return b.Priority }},
+	{"description", func(b model.Bug) any { return b.Description }},
+	{"steps", func(b model.Bug) any { return b.Steps }},
+	{"version", func(b model.Bug) any { return b.Version }},
+	{"platform", func(b model.Bug) any { return b.Platform }},
+	{"status", func(b model.Bug) any { return b.Status }},
+	{"resolution", func(b model.Bug) any { return b.Resolution }},
+	{"assigned_to", func(b model.Bug) any { return b.AssignedTo }},
+	{"labels", func(b model.Bug) any { return labelNames(b.Labels) }},
+}
+
+// historyValue encodes a field value for bug_history, or returns nil when it is unset.
+func historyValue(v any) *string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	switch s := string(encoded); s {
+	case "null", `""`, "[]":
+		return nil
+	default:
+		return &s
+	}
+}
+
+// diffBug lists the tracked fields that differ between two versions of a bug. With a nil
+// before (a new bug), it lists every field that is set.
+func diffBug(before *model.Bug, after model.Bug) []bugChange {
+	var changes []bugChange
+	for _, f := range trackedBugFields {
+		var old any
+		if before != nil {
+			old = f.value(*before)
+		}
+		updated := f.value(after)
+		oldValue, newValue := historyValue(old), historyValue(updated)
+		if (oldValue == nil && newValue == nil) || (oldValue != nil && newValue != nil && *oldValue == *newValue) {
+			continue
+		}
+		changes = append(changes, bugChange{field: f.name, old: old, new: updated})
+	}
+	return changes
+}
+
+// recordBugChanges appends changes made by actorID to the bug's history. It must run in
+// the transaction that makes the changes, so that the history can never diverge from the bug.
+func recordBugChanges(ctx context.Context, q querier, bugID, actorID string, changes []bugChange) error {
+	for _, ch := range changes {
+		_, err := q.Exec(ctx, `
+			INSERT INTO bug_history (bug_id, field, old_value, new_value, changed_by)
+			VALUES ($1, $2, $3::JSONB, $4::JSONB, $5)
+		`, bugID, ch.field, historyValue(ch.old), historyValue(ch.new), actorID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBugHistory returns a page of a bug's change history, oldest first. Any project role can
+// view it. Entries backfilled by the migration keep their times but may be out of order.
+// Supports optional query parameters:
+//   - field: only changes of this field (e.g. "priority")
+//   - page:  page number (default: 1)
+//   - limit: entries per page (default: 50, max: 200)
+func GetBugHistory(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Parse and validate pagination query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	var field *string
+	if f := strings.TrimSpace(c.Query("field")); f != "" {
+		field = &f
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permViewProject)
+	if !ok {
+		return
+	}
+
+	// Count entries (for pagination metadata)
+	var totalCount int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM bug_history
+		WHERE bug_id = $1 AND ($2::TEXT IS NULL OR field = $2)
+	`, bugID, field).Scan(&totalCount)
+	if err != nil {
+		logger.Log.Error("Failed to count bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug history"})
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT h.id, h.bug_id, h.field, h.old_value, h.new_value, h.changed_by, r.full_name, h.changed_at
+		FROM bug_history h
+		JOIN registrations r ON r.id = h.changed_by
+		WHERE h.bug_id = $1 AND ($2::TEXT IS NULL OR h.field = $2)
+		ORDER BY h.id
+		LIMIT $3 OFFSET $4
+	`, bugID, field, limit, offset)
+	if err != nil {
+		logger.Log.Error("Failed to fetch bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug history"})
+		return
+	}
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BugHistoryEntry, error) {
+		var h model.BugHistoryEntry
+		err := row.Scan(&h.ID, &h.BugID, &h.Field, &h.OldValue, &h.NewValue, &h.ChangedBy, &h.ChangedByName, &h.ChangedAt)
+		return h, err
+	})
+	if err != nil {
+		logger.Log.Error("Failed to scan bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BugHistoryResponse{
+		History:    history,
+		TotalCount: totalCount,
+		Page:       page,
+		Limit:      limit,
+	})
+}
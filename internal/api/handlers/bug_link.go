@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// incomingLinkTypes names each stored link type as seen from its target bug.
+var incomingLinkTypes = map[string]string{
+	model.BugLinkDuplicateOf: "duplicated_by",
+	model.BugLinkBlocks:      "blocked_by",
+	model.BugLinkRelatesTo:   model.BugLinkRelatesTo,
+}
+
+// linkedBug returns the summary of a bug shown at the other end of a link.
+func linkedBug(b model.Bug) model.LinkedBug {
+	return model.LinkedBug{ID: b.ID, ProjectID: b.ProjectID, BugNumber: b.BugNumber, Title: b.Title, Status: b.Status}
+}
+
+// linkView returns a stored link as seen from one of its bugs; other is the bug at the other end.
+func linkView(id, linkType string, outgoing bool, other model.LinkedBug, createdBy string, createdAt time.Time) model.BugLink {
+	link := model.BugLink{ID: id, Type: linkType, Direction: "outgoing", Bug: other, CreatedBy: createdBy, CreatedAt: createdAt}
+	if !outgoing {
+		link.Type = incomingLinkTypes[linkType]
+		link.Direction = "incoming"
+	}
+	return link
+}
+
+// linkHistoryValue identifies a link in the bug's history.
+func linkHistoryValue(link model.BugLink) map[string]string {
+	return map[string]string{"id": link.ID, "type": link.Type, "bug_number": link.Bug.BugNumber}
+}
+
+// loadBugLinks returns the links of a bug, oldest first. Links to bugs of projects the user
+// cannot see (see loadProjectAccess) are left out.
+func loadBugLinks(ctx context.Context, q querier, bugID string, user *middleware.UserContext) (model.BugLinks, error) {
+	links := model.BugLinks{Outgoing: []model.BugLink{}, Incoming: []model.BugLink{}}
+	rows, err := q.Query(ctx, `
+		SELECT l.id, l.link_type, l.source_bug_id = $1, o.id, o.project_id, o.bug_number, o.title, o.status,
+		       l.created_by, l.created_at
+		FROM bug_links l
+		JOIN bugs o ON o.id = CASE WHEN l.source_bug_id = $1 THEN l.target_bug_id ELSE l.source_bug_id END
+		JOIN projects p ON p.id = o.project_id
+		WHERE (l.source_bug_id = $1 OR l.target_bug_id = $1)
+		AND p.organisation_id = $3
+		AND (p.created_by = $2 OR EXISTS (
+			SELECT 1 FROM project_members m WHERE m.project_id = p.id AND m.user_id = $2
+		))
+		ORDER BY l.created_at, l.id
+	`, bugID, user.RegistrationID, user.OrganisationID)
+	if err != nil {
+		return links, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, linkType, createdBy string
+		var outgoing bool
+		var other model.LinkedBug
+		var createdAt time.Time
+		err := rows.Scan(&id, &linkType, &outgoing, &other.ID, &other.ProjectID, &other.BugNumber, &other.Title,
+			&other.Status, &createdBy, &createdAt)
+		if err != nil {
+			return links, err
+		}
+		link := linkView(id, linkType, outgoing, other, createdBy, createdAt)
+		if outgoing {
+			links.Outgoing = append(links.Outgoing, link)
+		} else {
+			links.Incoming = append(links.Incoming, link)
+		}
+	}
+	return links, rows.Err()
+}
+
+// recordLinkChange records a new or removed link in the history of both bugs, bumps their
+// updated_at and adds an event to the activity feed of the project the request was made in.
+// view is the link as seen from bug; otherView as seen from other.
+func recordLinkChange(ctx context.Context, tx pgx.Tx, projectID, actorID, eventType string, bug, other model.Bug, view, otherView model.BugLink) error {
+	for _, side := range []struct {
+		bugID string
+		link  model.BugLink
+	}{{bug.ID, view}, {other.ID, otherView}} {
+		change := bugChange{field: "links", new: linkHistoryValue(side.link)}
+		if eventType == model.ActivityBugUnlinked {
+			change = bugChange{field: "links", old: linkHistoryValue(side.link)}
+		}
+		if err := recordBugChanges(ctx, tx, side.bugID, actorID, []bugChange{change}); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE bugs SET updated_at = NOW() WHERE id = ANY($1::UUID[])`, []string{bug.ID, other.ID}); err != nil {
+		return err
+	}
+	return recordActivity(ctx, tx, projectID, actorID, eventType, &bug.ID, gin.H{
+		"bug_number": bug.BugNumber, "link_id": view.ID, "type": view.Type, "target_bug_number": other.BugNumber,
+	})
+}
+
+// lockLinkedBugs locks two bugs in a fixed order (so that concurrent link requests cannot
+// deadlock) and returns them by id.
+func lockLinkedBugs(ctx context.Context, tx pgx.Tx, firstID, secondID string) (map[string]model.Bug, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+bugColumns+`
+		FROM bugs
+		WHERE id = ANY($1::UUID[])
+		ORDER BY id
+		FOR UPDATE
+	`, []string{firstID, secondID})
+	if err != nil {
+		return nil, err
+	}
+	bugs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Bug, error) {
+		return scanBug(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Bug, len(bugs))
+	for _, b := range bugs {
+		byID[b.ID] = b
+	}
+	if len(byID) != 2 {
+		return nil, pgx.ErrNoRows
+	}
+	return byID, nil
+}
+
+// ListBugLinks returns a bug's incoming and outgoing links. Any project role can list them;
+// links to bugs of projects the caller cannot see are left out.
+func ListBugLinks(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permViewProject)
+	if !ok {
+		return
+	}
+
+	links, err := loadBugLinks(ctx, db.Pool, bugID, user)
+	if err != nil {
+		logger.Log.Error("Failed to fetch bug links: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+/*
+CreateBugLink links a bug to another bug of a project the caller can see in their
+organisation. Requires the update bug permission on the bug's project.
+
+  - duplicate_of closes the bug (if it is not closed yet) and sets its resolution to
+    "Duplicate of <bug_number>" (plus the project and bug UUID when the canonical bug is in
+    another project), which needs the permission to close it (see canTransitionBug) even
+    when the bug is already closed. A bug has at most one canonical bug, and the canonical
+    bug cannot itself be a duplicate, so duplicate chains never loop.
+  - blocks / blocked_by are rejected when they would close a cycle of blocking links.
+  - relates_to is undirected.
+
+The link is recorded in the history of both bugs and in the activity feed of the project.
+Error responses: 400 (validation / self link), 403 (role not allowed), 404 (bug or target not found),
+409 (already linked / cycle / target is a duplicate / project archived), 500 (database error)
+*/
+func CreateBugLink(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateBugLinkRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, access, ok := authorizeBug(c, ctx, user, permUpdateBug)
+	if !ok {
+		return
+	}
+	projectID := c.Param("id")
+
+	// The target must be in a project the caller can see; its existence is not revealed otherwise
+	targetProjectID := projectID
+	if input.TargetProjectID != "" && !strings.EqualFold(input.TargetProjectID, projectID) {
+		targetProjectID = input.TargetProjectID
+		targetAccess, err := loadProjectAccess(ctx, targetProjectID, user.RegistrationID, user.OrganisationID)
+		if err != nil {
+			logger.Log.Error("Failed to check project access: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+			return
+		}
+		if targetAccess.Role == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target bug not found"})
+			return
+		}
+		if targetAccess.Archived {
+			c.JSON(http.StatusConflict, gin.H{"error": "The target bug's project is archived"})
+			return
+		}
+	}
+
+	var targetID string
+	refID, refNumber := bugRefArgs(strings.TrimSpace(input.TargetBug))
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id FROM bugs
+		WHERE project_id = $1
+		AND (id = $2::UUID OR UPPER(bug_number) = UPPER($3))
+	`, targetProjectID, refID, refNumber).Scan(&targetID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target bug not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch target bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+	if targetID == bugID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A bug cannot be linked to itself"})
+		return
+	}
+
+	// blocked_by is stored as "blocks" from the other bug
+	linkType, sourceID, destID := input.Type, bugID, targetID
+	if input.Type == "blocked_by" {
+		linkType, sourceID, destID = model.BugLinkBlocks, targetID, bugID
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	locked, err := lockLinkedBugs(ctx, tx, bugID, targetID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+	bug, target := locked[bugID], locked[targetID]
+
+	switch linkType {
+	case model.BugLinkDuplicateOf:
+		var isDuplicate, targetIsDuplicate bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM bug_links WHERE source_bug_id = $1 AND link_type = 'duplicate_of'),
+			       EXISTS (SELECT 1 FROM bug_links WHERE source_bug_id = $2 AND link_type = 'duplicate_of')
+		`, bugID, targetID).Scan(&isDuplicate, &targetIsDuplicate)
+		if err != nil {
+			logger.Log.Error("Failed to check duplicate links: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+			return
+		}
+		if isDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": "Bug is already marked as a duplicate of another bug"})
+			return
+		}
+		if targetIsDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": target.BugNumber + " is itself a duplicate. Link to the bug it duplicates"})
+			return
+		}
+		// Closed bugs get a new resolution, which needs the same permission as closing them
+		if reason := canTransitionBug(user, access, bug, bug.Status, "closed"); reason != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": reason})
+			return
+		}
+
+	case model.BugLinkBlocks:
+		// Blocking links of the organisation are added one at a time: two concurrent
+		// links could otherwise each pass the check below and close a cycle together
+		if _, err := tx.Exec(ctx, `SELECT id FROM organisations WHERE id = $1 FOR NO KEY UPDATE`, user.OrganisationID); err != nil {
+			logger.Log.Error("Failed to lock organisation: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+			return
+		}
+		var cycle bool
+		err := tx.QueryRow(ctx, `
+			WITH RECURSIVE blocked (bug_id) AS (
+				SELECT $1::UUID
+				UNION
+				SELECT l.target_bug_id
+				FROM bug_links l
+				JOIN blocked b ON l.source_bug_id = b.bug_id
+				WHERE l.link_type = 'blocks'
+			)
+			SELECT EXISTS (SELECT 1 FROM blocked WHERE bug_id = $2)
+		`, destID, sourceID).Scan(&cycle)
+		if err != nil {
+			logger.Log.Error("Failed to check blocking links: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+			return
+		}
+		if cycle {
+			c.JSON(http.StatusConflict, gin.H{"error": "This link would create a cycle of blocking bugs"})
+			return
+		}
+	}
+
+	var linkID string
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, `
+		INSERT INTO bug_links (source_bug_id, target_bug_id, link_type, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, sourceID, destID, linkType, user.RegistrationID).Scan(&linkID, &createdAt)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "These bugs are already linked this way"})
+			return
+		}
+		logger.Log.Error("Failed to create bug link: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+
+	outgoing := sourceID == bugID
+	view := linkView(linkID, linkType, outgoing, linkedBug(target), user.RegistrationID, createdAt)
+	targetView := linkView(linkID, linkType, !outgoing, linkedBug(bug), user.RegistrationID, createdAt)
+	response := model.CreateBugLinkResponse{Link: view}
+
+	// A duplicate is closed with a resolution pointing to the canonical bug
+	if linkType == model.BugLinkDuplicateOf {
+		// Bug numbers are only unique within a project
+		resolution := "Duplicate of " + target.BugNumber
+		if target.ProjectID != bug.ProjectID {
+			var targetProjectName string
+			err := tx.QueryRow(ctx, `SELECT project_name FROM projects WHERE id = $1`, target.ProjectID).Scan(&targetProjectName)
+			if err != nil {
+				logger.Log.Error("Failed to fetch target project: " + err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+				return
+			}
+			resolution = fmt.Sprintf("Duplicate of %s in project %s (bug %s)", target.BugNumber, targetProjectName, target.ID)
+		}
+		closed, err := scanBug(tx.QueryRow(ctx, `
+			UPDATE bugs SET status = 'closed', resolution = $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING `+bugColumns,
+			resolution, bug.ID,
+		))
+		if err == nil && bug.Status != "closed" {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO bug_status_changes (bug_id, from_status, to_status, note, changed_by)
+				VALUES ($1, $2, 'closed', $3, $4)
+			`, bug.ID, bug.Status, resolution, user.RegistrationID)
+			if err == nil {
+				err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityBugStatusChanged, &bug.ID, gin.H{
+					"bug_number": bug.BugNumber, "from": bug.Status, "to": "closed",
+				})
+			}
+		}
+		if err == nil {
+			err = recordBugChanges(ctx, tx, bug.ID, user.RegistrationID, diffBug(&bug, closed))
+		}
+		if err != nil {
+			logger.Log.Error("Failed to close duplicate bug: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+			return
+		}
+		response.Bug = &closed
+	}
+
+	if err := recordLinkChange(ctx, tx, projectID, user.RegistrationID, model.ActivityBugLinked, bug, target, view, targetView); err != nil {
+		logger.Log.Error("Failed to record bug link: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug link: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link bugs"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteBugLink removes a link (incoming or outgoing) of a bug. Requires the update bug
+// permission on the bug's project. Removing a duplicate_of link does not reopen the bug.
+// Success response: 204 No Content
+// Error responses: 403 (role not allowed), 404 (bug or link not found), 409 (project archived),
+// 500 (database error)
+func DeleteBugLink(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permUpdateBug)
+	if !ok {
+		return
+	}
+	projectID := c.Param("id")
+
+	linkID := c.Param("linkId")
+	if _, err := uuid.Parse(linkID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug link"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	var sourceID, destID, linkType, createdBy string
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, `
+		DELETE FROM bug_links
+		WHERE id = $1 AND (source_bug_id = $2 OR target_bug_id = $2)
+		RETURNING source_bug_id, target_bug_id, link_type, created_by, created_at
+	`, linkID, bugID).Scan(&sourceID, &destID, &linkType, &createdBy, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+			return
+		}
+		logger.Log.Error("Failed to delete bug link: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug link"})
+		return
+	}
+
+	otherID := destID
+	if destID == bugID {
+		otherID = sourceID
+	}
+	locked, err := lockLinkedBugs(ctx, tx, bugID, otherID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch linked bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug link"})
+		return
+	}
+	bug, other := locked[bugID], locked[otherID]
+
+	outgoing := sourceID == bugID
+	view := linkView(linkID, linkType, outgoing, linkedBug(other), createdBy, createdAt)
+	otherView := linkView(linkID, linkType, !outgoing, linkedBug(bug), createdBy, createdAt)
+	if err := recordLinkChange(ctx, tx, projectID, user.RegistrationID, model.ActivityBugUnlinked, bug, other, view, otherView); err != nil {
+		logger.Log.Error("Failed to record bug link removal: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug link"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug link removal: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug link"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/*
+bugTransitions is the bug status state machine: for each current status it lists
+the statuses a bug may move to. Anything not listed here is rejected.
+
+	open        → in_progress, resolved, closed
+	in_progress → open, resolved, closed
+	resolved    → in_progress, closed, open (reopen)
+	closed      → open (reopen only)
+*/
+var bugTransitions = map[string]map[string]bool{
+	"open":        {"in_progress": true, "resolved": true, "closed": true},
+	"in_progress": {"open": true, "resolved": true, "closed": true},
+	"resolved":    {"in_progress": true, "closed": true, "open": true},
+	"closed":      {"open": true},
+}
+
+// isReopen reports whether a transition brings a finished bug back to "open".
+// Such transitions must be requested explicitly with reopen=true.
+func isReopen(from, to string) bool {
+	return to == "open" && (from == "resolved" || from == "closed")
+}
+
+/*
+canTransitionBug decides whether the user may move the bug to the target status, given
+their project access. It returns an empty string when allowed, or a human-readable reason.
+
+Rules:
+  - in_progress:   the assignee, or a role that can assign bugs (triage)
+  - resolved:      the assignee only
+  - closed:        the reporter, or a role that can close bugs
+  - open (reopen): the reporter, or a role that can close bugs
+  - open (other):  the assignee, or a role that can assign bugs (triage)
+*/
+func canTransitionBug(user *middleware.UserContext, access projectAccess, bug model.Bug, from, to string) string {
+	isReporter := bug.CreatedBy == user.RegistrationID
+	isAssignee := bug.AssignedTo != nil && *bug.AssignedTo == user.RegistrationID
+
+	switch {
+	case to == "resolved":
+		if bug.AssignedTo == nil {
+			return "Bug must be assigned before it can be resolved"
+		}
+		if !isAssignee {
+			return "Only the assignee can resolve this bug"
+		}
+	case to == "closed":
+		if !isReporter && !access.can(permCloseBug) {
+			return "Only the reporter or a project owner, maintainer or QA can close this bug"
+		}
+	case isReopen(from, to):
+		if !isReporter && !access.can(permCloseBug) {
+			return "Only the reporter or a project owner, maintainer or QA can reopen this bug"
+		}
+	default: // in_progress, or in_progress → open
+		if !isAssignee && !access.can(permAssignBug) {
+			return "Only the assignee or a project owner, maintainer or QA can change this bug's progress"
+		}
+	}
+	return ""
+}
+
+// UpdateBugStatus moves a bug through the status workflow defined by bugTransitions.
+// The bug can be referenced by its UUID or bug_number. Every change is recorded in
+// bug_status_changes with the acting user, the time and the optional note, and in the
+// bug's history.
+// Error responses: 400 (validation), 403 (project role not allowed), 404 (not found / no access),
+// 409 (transition not allowed / project archived), 500 (database error)
+func UpdateBugStatus(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	bugRef := strings.TrimSpace(c.Param("bugRef"))
+	if projectID == "" || bugRef == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID and bug reference are required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateBugStatusRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	access, ok := authorizeProject(c, ctx, projectID, user, permUpdateBug)
+	if !ok {
+		return
+	}
+
+	// The read, the update and the history insert must see the same row state,
+	// so everything runs in one transaction with the bug row locked.
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// Lock the bug for the rest of the transaction
+	bugID, bugNumber := bugRefArgs(bugRef)
+	lockQuery := `
+		SELECT ` + bugColumns + `
+		FROM bugs
+		WHERE project_id = $1
+		AND (id = $2::UUID OR UPPER(bug_number) = UPPER($3))
+		FOR UPDATE
+	`
+	bug, err := scanBug(tx.QueryRow(ctx, lockQuery, projectID, bugID, bugNumber))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+
+	// Validate the transition against the state machine
+	from, to := bug.Status, input.Status
+	if from == to {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bug is already " + to})
+		return
+	}
+	if !bugTransitions[from][to] {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot move a bug from " + from + " to " + to})
+		return
+	}
+	if isReopen(from, to) && !input.Reopen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Moving a " + from + " bug back to open requires reopen=true"})
+		return
+	}
+	if reason := canTransitionBug(user, access, bug, from, to); reason != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": reason})
+		return
+	}
+
+	// The note becomes the bug's resolution when it is finished; reopening clears it
+	var note *string
+	if trimmed := strings.TrimSpace(input.Note); trimmed != "" {
+		note = &trimmed
+	}
+	resolution := bug.Resolution
+	if to == "resolved" || to == "closed" {
+		if note != nil {
+			resolution = note
+		}
+	} else {
+		resolution = nil
+	}
+
+	updated, err := scanBug(tx.QueryRow(ctx, `
+		UPDATE bugs SET status = $1, resolution = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING `+bugColumns,
+		to, resolution, bug.ID,
+	))
+	if err != nil {
+		logger.Log.Error("Failed to update bug status: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+
+	// Record who made the change, when, and why
+	var change model.BugStatusChange
+	err = tx.QueryRow(ctx, `
+		INSERT INTO bug_status_changes (bug_id, from_status, to_status, note, changed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, bug_id, from_status, to_status, note, changed_by, changed_at
+	`, bug.ID, from, to, note, user.RegistrationID).Scan(
+		&change.ID, &change.BugID, &change.FromStatus, &change.ToStatus,
+		&change.Note, &change.ChangedBy, &change.ChangedAt,
+	)
+	if err != nil {
+		logger.Log.Error("Failed to record bug status change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+	if err := recordBugChanges(ctx, tx, bug.ID, user.RegistrationID, diffBug(&bug, updated)); err != nil {
+		logger.Log.Error("Failed to record bug history: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityBugStatusChanged, &bug.ID, gin.H{
+		"bug_number": bug.BugNumber, "from": from, "to": to,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug status change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.UpdateBugStatusResponse{
+		Bug:    updated,
+		Change: change,
+	})
+}
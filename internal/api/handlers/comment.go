@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// commentColumns selects a comment with its author's name; the body of deleted comments is NULL.
+// Expects bug_comments aliased as "cm" and registrations as "a"; keep in sync with scanComment.
+const commentColumns = `cm.id, cm.bug_id, cm.parent_id, cm.author_id, a.full_name,
+	CASE WHEN cm.deleted_at IS NULL THEN cm.body END, cm.edited_at, cm.deleted_at, cm.created_at`
+
+// scanComment reads a single row selected with commentColumns.
+func scanComment(row pgx.Row) (model.BugComment, error) {
+	var cm model.BugComment
+	err := row.Scan(
+		&cm.ID, &cm.BugID, &cm.ParentID, &cm.AuthorID, &cm.AuthorName,
+		&cm.Body, &cm.EditedAt, &cm.DeletedAt, &cm.CreatedAt,
+	)
+	return cm, err
+}
+
+// fetchComment returns a comment of the bug by the :commentId parameter, or pgx.ErrNoRows.
+func fetchComment(ctx context.Context, c *gin.Context, bugID string) (model.BugComment, error) {
+	commentID := c.Param("commentId")
+	if _, err := uuid.Parse(commentID); err != nil {
+		return model.BugComment{}, pgx.ErrNoRows
+	}
+	return scanComment(db.Pool.QueryRow(ctx, `
+		SELECT `+commentColumns+`
+		FROM bug_comments cm
+		JOIN registrations a ON a.id = cm.author_id
+		WHERE cm.id = $1 AND cm.bug_id = $2
+	`, commentID, bugID))
+}
+
+// CreateBugComment adds a comment to a bug, or a reply when parent_id names a top-level
+// comment of the same bug. The author is the authenticated user, and the bug's updated_at
+// is bumped. @mentions of project members are recorded (see recordMentions); the response
+// flags the ones that did not resolve. Requires the comment permission (any project role
+// except viewer).
+// Error responses: 400 (validation / reply to a reply), 403 (role not allowed), 404 (bug or
+// parent not found), 409 (parent deleted / project archived), 500 (database error)
+func CreateBugComment(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateBugCommentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body cannot be empty"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permCommentBug)
+	if !ok {
+		return
+	}
+
+	// Replies attach to a live top-level comment of the same bug
+	if input.ParentID != nil {
+		var grandparentID *string
+		var parentDeleted bool
+		err := db.Pool.QueryRow(ctx,
+			`SELECT parent_id, deleted_at IS NOT NULL FROM bug_comments WHERE id = $1 AND bug_id = $2`,
+			*input.ParentID, bugID,
+		).Scan(&grandparentID, &parentDeleted)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+				return
+			}
+			logger.Log.Error("Failed to fetch parent comment: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+			return
+		}
+		if grandparentID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Replies can only be made to top-level comments"})
+			return
+		}
+		if parentDeleted {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot reply to a deleted comment"})
+			return
+		}
+	}
+
+	// The comment and the bug's updated_at bump are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	comment, err := scanComment(tx.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO bug_comments (bug_id, parent_id, author_id, body)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *
+		)
+		SELECT `+commentColumns+`
+		FROM inserted cm
+		JOIN registrations a ON a.id = cm.author_id
+	`, bugID, input.ParentID, user.RegistrationID, body))
+	if err != nil {
+		logger.Log.Error("Failed to insert comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	candidates, err := loadMentionCandidates(ctx, tx, c.Param("id"))
+	if err == nil {
+		comment.Mentions, err = recordMentions(ctx, tx, candidates, bugID, &comment.ID, user.RegistrationID, body)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to record mentions: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	var bugNumber string
+	err = tx.QueryRow(ctx, `UPDATE bugs SET updated_at = NOW() WHERE id = $1 RETURNING bug_number`, bugID).Scan(&bugNumber)
+	if err != nil {
+		logger.Log.Error("Failed to update bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	err = recordActivity(ctx, tx, c.Param("id"), user.RegistrationID, model.ActivityCommentCreated, &bugID, gin.H{
+		"bug_number": bugNumber, "comment_id": comment.ID, "parent_id": comment.ParentID,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListBugComments returns a page of a bug's top-level comments, oldest first, each with
+// all its replies (oldest first). Any project role can list them.
+// Supports optional query parameters:
+//   - page:  page number (default: 1)
+//   - limit: top-level comments per page (default: 20, max: 100)
+func ListBugComments(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Parse and validate pagination query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permViewProject)
+	if !ok {
+		return
+	}
+
+	// Count top-level comments (for pagination metadata)
+	var totalCount int
+	err := db.Pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM bug_comments WHERE bug_id = $1 AND parent_id IS NULL`, bugID,
+	).Scan(&totalCount)
+	if err != nil {
+		logger.Log.Error("Failed to count comments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	// One query for the page of top-level comments and their replies: replies sort right
+	// after their parent, so the thread can be assembled in a single pass
+	rows, err := db.Pool.Query(ctx, `
+		WITH page AS (
+			SELECT id, created_at FROM bug_comments
+			WHERE bug_id = $1 AND parent_id IS NULL
+			ORDER BY created_at, id
+			LIMIT $2 OFFSET $3
+		)
+		SELECT `+commentColumns+`
+		FROM page
+		JOIN bug_comments cm ON cm.id = page.id OR cm.parent_id = page.id
+		JOIN registrations a ON a.id = cm.author_id
+		ORDER BY page.created_at, page.id, cm.parent_id NULLS FIRST, cm.created_at, cm.id
+	`, bugID, limit, offset)
+	if err != nil {
+		logger.Log.Error("Failed to query comments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows (empty slice, not nil, for clean JSON [])
+	comments := []model.BugComment{}
+	for rows.Next() {
+		cm, err := scanComment(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan comment row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+			return
+		}
+		if cm.ParentID == nil {
+			comments = append(comments, cm)
+			continue
+		}
+		parent := &comments[len(comments)-1]
+		parent.Replies = append(parent.Replies, cm)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BugCommentListResponse{
+		Comments:   comments,
+		TotalCount: totalCount,
+		Page:       page,
+		Limit:      limit,
+	})
+}
+
+// UpdateBugComment changes the body of a comment. Only the author can edit it, and only
+// within COMMENT_EDIT_WINDOW_MINUTES of posting it (0 = no limit). Members newly
+// @mentioned by the edit are notified.
+// Error responses: 400 (validation), 403 (not the author / edit window over), 404 (not found),
+// 409 (comment deleted / project archived), 500 (database error)
+func UpdateBugComment(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateBugCommentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body cannot be empty"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, _, ok := authorizeBug(c, ctx, user, permCommentBug)
+	if !ok {
+		return
+	}
+
+	comment, err := fetchComment(ctx, c, bugID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	if comment.AuthorID != user.RegistrationID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can edit a comment"})
+		return
+	}
+	if comment.DeletedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Comment has been deleted"})
+		return
+	}
+	window := time.Duration(config.Cfg.CommentEditWindowMinutes) * time.Minute
+	if window > 0 && time.Since(comment.CreatedAt) > window {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("Comments can only be edited within %d minutes of posting", config.Cfg.CommentEditWindowMinutes),
+		})
+		return
+	}
+
+	// The edit and the mentions it adds are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// Re-check deletion in the UPDATE in case the comment was deleted meanwhile
+	comment, err = scanComment(tx.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE bug_comments SET body = $2, edited_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING *
+		)
+		SELECT `+commentColumns+`
+		FROM updated cm
+		JOIN registrations a ON a.id = cm.author_id
+	`, comment.ID, body))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Comment has been deleted"})
+			return
+		}
+		logger.Log.Error("Failed to update comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	candidates, err := loadMentionCandidates(ctx, tx, c.Param("id"))
+	if err == nil {
+		comment.Mentions, err = recordMentions(ctx, tx, candidates, bugID, &comment.ID, user.RegistrationID, body)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to record mentions: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteBugComment soft-deletes a comment: it stays in the thread (with its replies) but its
+// body is no longer returned. Authors can delete their own comments; project owners and
+// maintainers can delete any comment.
+// Success response: 204 No Content
+// Error responses: 403 (not allowed), 404 (not found), 409 (already deleted / project archived), 500
+func DeleteBugComment(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bugID, access, ok := authorizeBug(c, ctx, user, permCommentBug)
+	if !ok {
+		return
+	}
+
+	comment, err := fetchComment(ctx, c, bugID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+	if comment.AuthorID != user.RegistrationID && !access.can(permModerateComments) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your project role (" + access.Role + ") does not allow you to " + permModerateComments.action})
+		return
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE bug_comments SET deleted_at = NOW(), deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, comment.ID, user.RegistrationID)
+	if err != nil {
+		logger.Log.Error("Failed to delete comment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Comment is already deleted"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,729 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/mailer"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// invitationColumns selects an invitation with its computed status and project name.
+// Expects invitations aliased as "i" and a LEFT JOIN of projects aliased as "p";
+// keep in sync with scanInvitation.
+const invitationColumns = `i.id, i.organisation_id, i.email, i.project_id, p.project_name, i.project_role,
+	CASE
+		WHEN i.accepted_at IS NOT NULL THEN 'accepted'
+		WHEN i.revoked_at IS NOT NULL THEN 'revoked'
+		WHEN i.expires_at <= NOW() THEN 'expired'
+		ELSE 'pending'
+	END,
+	i.expires_at, i.accepted_at, i.accepted_by, i.revoked_at, i.send_count, i.last_sent_at, i.invited_by, i.created_at`
+
+// scanInvitation reads a single row selected with invitationColumns.
+func scanInvitation(row pgx.Row) (model.Invitation, error) {
+	var i model.Invitation
+	err := row.Scan(
+		&i.ID, &i.OrganisationID, &i.Email, &i.ProjectID, &i.ProjectName, &i.ProjectRole, &i.Status,
+		&i.ExpiresAt, &i.AcceptedAt, &i.AcceptedBy, &i.RevokedAt, &i.SendCount, &i.LastSentAt,
+		&i.InvitedBy, &i.CreatedAt,
+	)
+	return i, err
+}
+
+// generateInvitationToken returns a new random invitation token (43 base64url characters)
+// and the SHA-256 hash to store.
+func generateInvitationToken() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashInvitationToken(token), nil
+}
+
+// hashInvitationToken returns the hex-encoded SHA-256 of a token, as stored in invitations.token_hash.
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// invitationTTL returns how long a newly issued invitation link stays valid.
+func invitationTTL() time.Duration {
+	hours := config.Cfg.InvitationTTLHours
+	if hours <= 0 {
+		hours = 72
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+/*
+sendInvitationEmail emails the invitation link for token and reports whether it was delivered.
+Delivery failures are logged, not returned: the invitation exists either way and can be
+resent. It runs after the database work, with its own timeout, because SMTP servers can be slow.
+*/
+func sendInvitationEmail(inv model.Invitation, token, inviterName string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var organisationName string
+	err := db.Pool.QueryRow(ctx, `SELECT name FROM organisations WHERE id = $1`, inv.OrganisationID).Scan(&organisationName)
+	if err != nil {
+		logger.Log.Error("Failed to fetch organisation for invitation email: " + err.Error())
+		return false
+	}
+
+	link, err := url.Parse(config.Cfg.InviteAcceptURL)
+	if err != nil {
+		logger.Log.Error("Invalid INVITE_ACCEPT_URL: " + err.Error())
+		return false
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	subject := fmt.Sprintf("You're invited to join %s on TaskDesk", organisationName)
+	what := organisationName
+	if inv.ProjectName != nil && inv.ProjectRole != nil {
+		subject = fmt.Sprintf("You're invited to the %s project on TaskDesk", *inv.ProjectName)
+		what = fmt.Sprintf("the %s project of %s as %s", *inv.ProjectName, organisationName, *inv.ProjectRole)
+	}
+	body := fmt.Sprintf(`Hi,
+
+%s invited you to join %s on TaskDesk.
+
+Accept the invitation by signing in with this email address (%s) and opening:
+%s
+
+The link can be used once and expires on %s.
+If you were not expecting this invitation, you can ignore this email.
+`, inviterName, what, inv.Email, link.String(), inv.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"))
+
+	if err := mailer.Default.Send(ctx, mailer.Message{To: inv.Email, Subject: subject, Body: body}); err != nil {
+		logger.Log.Error("Failed to send invitation email to " + inv.Email + ": " + err.Error())
+		return false
+	}
+	return true
+}
+
+/*
+createInvitation invites input.Email to the user's organisation, or into projectID (when not
+nil) with the given project role, and emails the link. Callers check the permission first.
+Writes 201 with the invitation, or:
+  - 409 if the email belongs to another organisation, is already in the organisation
+    (organisation invitations) or the project, or an invitation is already pending
+  - 500 on database errors
+
+Expired invitations for the same email and destination are revoked and replaced.
+*/
+func createInvitation(c *gin.Context, ctx context.Context, user *middleware.UserContext, email string, projectID, role *string) {
+	// An existing registration decides whether the invitation makes sense at all
+	var sameOrganisation, isProjectMember bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT r.organisation_id = $2,
+		       EXISTS (SELECT 1 FROM project_members m WHERE m.project_id = $3::UUID AND m.user_id = r.id)
+		       OR EXISTS (SELECT 1 FROM projects p WHERE p.id = $3::UUID AND p.created_by = r.id)
+		FROM registrations r
+		WHERE LOWER(r.email) = LOWER($1)
+	`, email, user.OrganisationID, projectID).Scan(&sameOrganisation, &isProjectMember)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Not registered yet: accepting the invitation registers them
+	case err != nil:
+		logger.Log.Error("Failed to look up invitee: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	case !sameOrganisation:
+		c.JSON(http.StatusConflict, gin.H{"error": "This email is registered in another organisation"})
+		return
+	case projectID == nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of the organisation"})
+		return
+	case isProjectMember:
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this project"})
+		return
+	}
+
+	// Expired invitations would otherwise keep blocking a new one (see uq_invitations_open)
+	_, err = db.Pool.Exec(ctx, `
+		UPDATE invitations SET revoked_at = NOW()
+		WHERE organisation_id = $1 AND LOWER(email) = LOWER($2) AND project_id IS NOT DISTINCT FROM $3::UUID
+		AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= NOW()
+	`, user.OrganisationID, email, projectID)
+	if err != nil {
+		logger.Log.Error("Failed to revoke expired invitations: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	token, hash, err := generateInvitationToken()
+	if err != nil {
+		logger.Log.Error("Failed to generate invitation token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	invitation, err := scanInvitation(db.Pool.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO invitations (organisation_id, email, project_id, project_role, token_hash, expires_at, invited_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING *
+		)
+		SELECT `+invitationColumns+`
+		FROM inserted i
+		LEFT JOIN projects p ON p.id = i.project_id
+	`, user.OrganisationID, email, projectID, role, hash, time.Now().Add(invitationTTL()), user.RegistrationID))
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "An invitation for this email is already pending; resend it instead"})
+			return
+		}
+		logger.Log.Error("Failed to insert invitation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.InvitationResponse{
+		Invitation: invitation,
+		EmailSent:  sendInvitationEmail(invitation, token, inviterName(ctx, user)),
+	})
+}
+
+// inviterName returns the user's full name for invitation emails, or their email if the
+// lookup fails.
+func inviterName(ctx context.Context, user *middleware.UserContext) string {
+	var name string
+	if err := db.Pool.QueryRow(ctx, `SELECT full_name FROM registrations WHERE id = $1`, user.RegistrationID).Scan(&name); err != nil {
+		return user.Email
+	}
+	return name
+}
+
+// isOrganisationManager reports whether the user's global role may manage
+// organisation-level invitations (PM or Admin).
+func isOrganisationManager(user *middleware.UserContext) bool {
+	return user.Role == model.RolePM || user.Role == model.RoleAdmin
+}
+
+// CreateInvitation invites an email address to the user's organisation (without a project).
+// Only accessible by users with the "PM" or "Admin" role.
+// Error responses: 400 (validation / role given), 409 (see createInvitation), 500 (database error)
+func CreateInvitation(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	if input.Role != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role is only allowed for project invitations"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	createInvitation(c, ctx, user, strings.ToLower(strings.TrimSpace(input.Email)), nil, nil)
+}
+
+// CreateProjectInvitation invites an email address into a project with a project role
+// (default "developer"). Requires the manage members permission (owner or maintainer).
+// Error responses: 400 (validation), 403 (role not allowed), 404 (project not found),
+// 409 (project archived, or see createInvitation), 500 (database error)
+func CreateProjectInvitation(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	if input.Role == "" {
+		input.Role = model.ProjectRoleDeveloper
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageMembers); !ok {
+		return
+	}
+
+	createInvitation(c, ctx, user, strings.ToLower(strings.TrimSpace(input.Email)), &projectID, &input.Role)
+}
+
+// listInvitations writes the organisation's invitations (of projectID only, when not nil),
+// newest first. Supports an optional ?status= filter (pending, accepted, revoked, expired).
+func listInvitations(c *gin.Context, ctx context.Context, organisationID string, projectID *string) {
+	status := optionalParam(c, "status")
+	if status != nil {
+		switch *status {
+		case model.InvitationPending, model.InvitationAccepted, model.InvitationRevoked, model.InvitationExpired:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be one of: pending, accepted, revoked, expired"})
+			return
+		}
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT * FROM (
+			SELECT `+invitationColumns+`
+			FROM invitations i
+			LEFT JOIN projects p ON p.id = i.project_id
+			WHERE i.organisation_id = $1
+			AND ($2::UUID IS NULL OR i.project_id = $2)
+		) AS listed (id, organisation_id, email, project_id, project_name, project_role, status,
+		             expires_at, accepted_at, accepted_by, revoked_at, send_count, last_sent_at,
+		             invited_by, created_at)
+		WHERE ($3::VARCHAR IS NULL OR status = $3)
+		ORDER BY created_at DESC
+	`, organisationID, projectID, status)
+	if err != nil {
+		logger.Log.Error("Failed to query invitations: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows (empty slice, not nil, for clean JSON [])
+	invitations := []model.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan invitation row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+			return
+		}
+		invitations = append(invitations, inv)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.InvitationListResponse{
+		Invitations: invitations,
+		Count:       len(invitations),
+	})
+}
+
+// ListInvitations returns all invitations of the user's organisation, including project
+// invitations. Only accessible by users with the "PM" or "Admin" role.
+func ListInvitations(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listInvitations(c, ctx, user.OrganisationID, nil)
+}
+
+// ListProjectInvitations returns the invitations into a project.
+// Requires the manage invitations permission (owner or maintainer).
+func ListProjectInvitations(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageInvitations); !ok {
+		return
+	}
+
+	listInvitations(c, ctx, user.OrganisationID, &projectID)
+}
+
+/*
+authorizeInvitation loads an invitation of the user's organisation by the :invitationId
+parameter and checks that the user may manage it: organisation invitations need the PM or
+Admin role, project invitations the given project permission. It writes the error response
+(404 for unknown invitations, 403 / 409 from the permission check, 500) and returns false
+when the user may not.
+*/
+func authorizeInvitation(c *gin.Context, ctx context.Context, user *middleware.UserContext, perm projectPermission) (model.Invitation, bool) {
+	invitationID := c.Param("invitationId")
+	if _, err := uuid.Parse(invitationID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return model.Invitation{}, false
+	}
+
+	invitation, err := scanInvitation(db.Pool.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations i
+		LEFT JOIN projects p ON p.id = i.project_id
+		WHERE i.id = $1 AND i.organisation_id = $2
+	`, invitationID, user.OrganisationID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+			return invitation, false
+		}
+		logger.Log.Error("Failed to fetch invitation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitation"})
+		return invitation, false
+	}
+
+	if invitation.ProjectID == nil {
+		if !isOrganisationManager(user) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return invitation, false
+		}
+		return invitation, true
+	}
+	_, ok := authorizeProject(c, ctx, *invitation.ProjectID, user, perm)
+	return invitation, ok
+}
+
+// ResendInvitation issues a new link for a pending or expired invitation, which invalidates
+// the previous link and restarts the expiry, and emails it again.
+// Error responses: 403 (not allowed), 404 (not found), 409 (accepted, revoked or project
+// archived), 500 (database error)
+func ResendInvitation(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, ok := authorizeInvitation(c, ctx, user, permManageMembers)
+	if !ok {
+		return
+	}
+
+	token, hash, err := generateInvitationToken()
+	if err != nil {
+		logger.Log.Error("Failed to generate invitation token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend invitation"})
+		return
+	}
+
+	// The state is re-checked in the UPDATE in case the invitation was accepted meanwhile
+	invitation, err = scanInvitation(db.Pool.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE invitations
+			SET token_hash = $2, expires_at = $3, send_count = send_count + 1, last_sent_at = NOW()
+			WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+			RETURNING *
+		)
+		SELECT `+invitationColumns+`
+		FROM updated i
+		LEFT JOIN projects p ON p.id = i.project_id
+	`, invitation.ID, hash, time.Now().Add(invitationTTL())))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Invitation was already accepted or revoked"})
+			return
+		}
+		logger.Log.Error("Failed to update invitation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.InvitationResponse{
+		Invitation: invitation,
+		EmailSent:  sendInvitationEmail(invitation, token, inviterName(ctx, user)),
+	})
+}
+
+// RevokeInvitation revokes a pending or expired invitation; its link stops working immediately.
+// Error responses: 403 (not allowed), 404 (not found), 409 (already accepted or revoked), 500
+func RevokeInvitation(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, ok := authorizeInvitation(c, ctx, user, permManageInvitations)
+	if !ok {
+		return
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE invitations SET revoked_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, invitation.ID)
+	if err != nil {
+		logger.Log.Error("Failed to revoke invitation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invitation"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation was already accepted or revoked"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+AcceptInvitation redeems an invitation token for the signed-in invitee. It requires a valid
+Supabase JWT whose email matches the invited address, but no registration: invitees
+without one are registered into the inviting organisation with the default role (full_name
+is then required). Project invitations also add the project membership with the invited role.
+Everything happens in one transaction, and the token cannot be used again.
+
+Success response: 200 OK with the registration and the accepted invitation
+Error responses: 400 (validation / full_name missing), 403 (signed in with another email),
+404 (unknown token), 409 (registered in another organisation, or project archived),
+410 (invitation expired, revoked or already accepted), 500 (database error)
+*/
+func AcceptInvitation(c *gin.Context) {
+	// Get the verified Supabase identity (set by RequireToken)
+	identity := middleware.GetTokenIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// Lock the invitation so the token is redeemed at most once
+	invitation, err := scanInvitation(tx.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations i
+		LEFT JOIN projects p ON p.id = i.project_id
+		WHERE i.token_hash = $1
+		FOR UPDATE OF i
+	`, hashInvitationToken(strings.TrimSpace(input.Token))))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch invitation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+	switch invitation.Status {
+	case model.InvitationAccepted:
+		c.JSON(http.StatusGone, gin.H{"error": "Invitation has already been accepted"})
+		return
+	case model.InvitationRevoked:
+		c.JSON(http.StatusGone, gin.H{"error": "Invitation has been revoked"})
+		return
+	case model.InvitationExpired:
+		c.JSON(http.StatusGone, gin.H{"error": "Invitation has expired. Ask for it to be resent"})
+		return
+	}
+
+	if identity.Email == "" || !strings.EqualFold(identity.Email, invitation.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sign in with the invited email address to accept this invitation"})
+		return
+	}
+
+	// Find the invitee's registration: bound to this Supabase user, or not yet bound to anyone
+	var registrationID, organisationID string
+	err = tx.QueryRow(ctx, `
+		SELECT id, organisation_id
+		FROM registrations
+		WHERE supabase_user_id = $1
+		OR (LOWER(email) = LOWER($2) AND supabase_user_id IS NULL AND NOT is_service_account)
+		ORDER BY supabase_user_id IS NULL
+		LIMIT 1
+		FOR UPDATE
+	`, identity.SupabaseUserID, identity.Email).Scan(&registrationID, &organisationID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		fullName := strings.TrimSpace(input.FullName)
+		if fullName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "full_name is required to register"})
+			return
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO registrations (full_name, email, organisation_id, role, supabase_user_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, fullName, identity.Email, invitation.OrganisationID, model.DefaultRole, identity.SupabaseUserID).Scan(&registrationID)
+		if err != nil {
+			if db.IsUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "This email is already registered to another account"})
+				return
+			}
+			logger.Log.Error("Failed to insert registration: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+			return
+		}
+	case err != nil:
+		logger.Log.Error("Failed to fetch registration: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	case organisationID != invitation.OrganisationID:
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already registered in another organisation"})
+		return
+	default:
+		// Bind a registration created before the invitee first signed in (no-op if already bound)
+		_, err = tx.Exec(ctx,
+			`UPDATE registrations SET supabase_user_id = $1 WHERE id = $2 AND supabase_user_id IS NULL`,
+			identity.SupabaseUserID, registrationID,
+		)
+		if err != nil {
+			logger.Log.Error("Failed to link registration: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+			return
+		}
+	}
+
+	if invitation.ProjectID != nil {
+		var archived, isOwner bool
+		err = tx.QueryRow(ctx,
+			`SELECT archived_at IS NOT NULL, created_by = $2 FROM projects WHERE id = $1`,
+			*invitation.ProjectID, registrationID,
+		).Scan(&archived, &isOwner)
+		if err != nil {
+			logger.Log.Error("Failed to fetch project: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+			return
+		}
+		if archived {
+			c.JSON(http.StatusConflict, gin.H{"error": "Project is archived and cannot accept new members"})
+			return
+		}
+
+		// The owner is implicit, and an existing membership keeps its role
+		if !isOwner {
+			tag, err := tx.Exec(ctx, `
+				INSERT INTO project_members (project_id, user_id, role)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (project_id, user_id) DO NOTHING
+			`, *invitation.ProjectID, registrationID, *invitation.ProjectRole)
+			if err == nil {
+				err = syncMemberCount(ctx, tx, *invitation.ProjectID)
+			}
+			if err == nil && tag.RowsAffected() > 0 {
+				err = recordActivity(ctx, tx, *invitation.ProjectID, registrationID, model.ActivityMemberJoined, nil, gin.H{
+					"user_id": registrationID, "role": *invitation.ProjectRole,
+				})
+			}
+			if err != nil {
+				logger.Log.Error("Failed to add project member: " + err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+				return
+			}
+		}
+	}
+
+	var response model.AcceptInvitationResponse
+	response.Invitation, err = scanInvitation(tx.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE invitations SET accepted_at = NOW(), accepted_by = $2
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+invitationColumns+`
+		FROM updated i
+		LEFT JOIN projects p ON p.id = i.project_id
+	`, invitation.ID, registrationID))
+	if err != nil {
+		logger.Log.Error("Failed to mark invitation accepted: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	response.User, err = scanRegistration(tx.QueryRow(ctx, `
+		SELECT `+registrationColumns+`
+		FROM registrations r
+		JOIN organisations o ON o.id = r.organisation_id
+		WHERE r.id = $1
+	`, registrationID))
+	if err != nil {
+		logger.Log.Error("Failed to fetch registration: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit invitation acceptance: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	// The registration may have just been created or bound to this Supabase user
+	middleware.InvalidateUser(registrationID)
+
+	c.JSON(http.StatusOK, response)
+}
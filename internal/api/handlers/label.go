@@ -0,0 +1,714 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxBugLabels caps the labels on a single bug.
+const maxBugLabels = 20
+
+// labelColorPattern matches "#rrggbb" colors (either case; stored lowercase).
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// labelColumns selects a catalog label with the number of bugs carrying it.
+// Expects labels aliased as "l"; keep in sync with scanLabel.
+const labelColumns = `l.id, l.project_id, l.name, l.color, l.description,
+	(SELECT COUNT(*) FROM bug_labels bl WHERE bl.label_id = l.id), l.created_by, l.created_at, l.updated_at`
+
+// scanLabel reads a single row selected with labelColumns.
+func scanLabel(row pgx.Row) (model.Label, error) {
+	var l model.Label
+	err := row.Scan(&l.ID, &l.ProjectID, &l.Name, &l.Color, &l.Description, &l.BugCount,
+		&l.CreatedBy, &l.CreatedAt, &l.UpdatedAt)
+	return l, err
+}
+
+// normalizeLabelName trims a label name and returns a message when it is not allowed.
+// Commas are rejected because the bug list takes comma-separated label names.
+func normalizeLabelName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", "Label name cannot be empty"
+	case len(name) > 50:
+		return "", "Label name must be at most 50 characters"
+	case strings.Contains(name, ","):
+		return "", "Label name cannot contain commas"
+	}
+	return name, ""
+}
+
+// normalizeLabelNames validates label names sent for a bug and removes duplicates
+// (ignoring case). It returns a message when a name is invalid or there are too many.
+func normalizeLabelNames(names []string) ([]string, string) {
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, raw := range names {
+		name, msg := normalizeLabelName(raw)
+		if msg != "" {
+			return nil, msg
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) > maxBugLabels {
+		return nil, fmt.Sprintf("A bug can have at most %d labels", maxBugLabels)
+	}
+	return normalized, ""
+}
+
+// lowerAll returns the names in lowercase, for case-insensitive SQL comparisons.
+func lowerAll(names []string) []string {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	return lowered
+}
+
+// labelNames returns the names of a bug's labels, for the bug's history.
+func labelNames(labels []model.BugLabel) []string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// loadBugLabels returns the labels of each bug, sorted by name.
+func loadBugLabels(ctx context.Context, q querier, bugIDs []string) (map[string][]model.BugLabel, error) {
+	rows, err := q.Query(ctx, `
+		SELECT bl.bug_id, l.id, l.name, l.color
+		FROM bug_labels bl
+		JOIN labels l ON l.id = bl.label_id
+		WHERE bl.bug_id = ANY($1::UUID[])
+		ORDER BY LOWER(l.name)
+	`, bugIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[string][]model.BugLabel, len(bugIDs))
+	for rows.Next() {
+		var bugID string
+		var l model.BugLabel
+		if err := rows.Scan(&bugID, &l.ID, &l.Name, &l.Color); err != nil {
+			return nil, err
+		}
+		labels[bugID] = append(labels[bugID], l)
+	}
+	return labels, rows.Err()
+}
+
+// attachBugLabels fills in the Labels of each bug.
+func attachBugLabels(ctx context.Context, q querier, bugs []model.Bug) error {
+	if len(bugs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(bugs))
+	for i := range bugs {
+		ids[i] = bugs[i].ID
+	}
+	labels, err := loadBugLabels(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range bugs {
+		bugs[i].Labels = labels[bugs[i].ID]
+	}
+	return nil
+}
+
+// unknownLabels returns the names (as sent) that are not in the project's catalog.
+func unknownLabels(ctx context.Context, projectID string, names []string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT LOWER(name) FROM labels WHERE project_id = $1 AND LOWER(name) = ANY($2)
+	`, projectID, lowerAll(names))
+	if err != nil {
+		return nil, err
+	}
+	known, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	unknown := []string{}
+	for _, name := range names {
+		if !slices.Contains(known, strings.ToLower(name)) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown, nil
+}
+
+// labelRefArgs splits a label reference from the URL into the lookup parameters of the
+// "(l.id = $n OR LOWER(l.name) = LOWER($m))" filter, like bugRefArgs does for bugs.
+func labelRefArgs(labelRef string) (id *string, name *string) {
+	if _, err := uuid.Parse(labelRef); err == nil {
+		return &labelRef, nil
+	}
+	return nil, &labelRef
+}
+
+// saveBugLabelChange finishes a change of a bug's labels inside the caller's transaction:
+// it bumps the bug's updated_at and records the change in the bug's history and the
+// project's activity feed. Nothing is recorded when the labels did not change.
+func saveBugLabelChange(ctx context.Context, tx pgx.Tx, projectID, bugID, bugNumber, actorID string, before, after []model.BugLabel) error {
+	oldNames, newNames := labelNames(before), labelNames(after)
+	if slices.Equal(oldNames, newNames) {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE bugs SET updated_at = NOW() WHERE id = $1`, bugID); err != nil {
+		return err
+	}
+	change := bugChange{field: "labels", old: oldNames, new: newNames}
+	if err := recordBugChanges(ctx, tx, bugID, actorID, []bugChange{change}); err != nil {
+		return err
+	}
+
+	added, removed := []string{}, []string{}
+	for _, name := range newNames {
+		if !slices.Contains(oldNames, name) {
+			added = append(added, name)
+		}
+	}
+	for _, name := range oldNames {
+		if !slices.Contains(newNames, name) {
+			removed = append(removed, name)
+		}
+	}
+	return recordActivity(ctx, tx, projectID, actorID, model.ActivityBugLabelsChanged, &bugID, gin.H{
+		"bug_number": bugNumber, "added": added, "removed": removed,
+	})
+}
+
+// ListLabels returns a project's label catalog, sorted by name, with the number of bugs
+// carrying each label. Any project role can list it.
+func ListLabels(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+labelColumns+`
+		FROM labels l
+		WHERE l.project_id = $1
+		ORDER BY LOWER(l.name)
+	`, projectID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch labels"})
+		return
+	}
+	labels, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Label, error) {
+		return scanLabel(row)
+	})
+	if err != nil {
+		logger.Log.Error("Failed to scan labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch labels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LabelListResponse{
+		Labels:     labels,
+		TotalCount: len(labels),
+	})
+}
+
+// CreateLabel adds a label to a project's catalog. Requires the manage labels permission
+// (owner or maintainer).
+// Error responses: 400 (validation), 403 (role not allowed), 404 (project not found),
+// 409 (name already used in the project / project archived), 500 (database error)
+func CreateLabel(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.CreateLabelRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	name, msg := normalizeLabelName(input.Name)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if !labelColorPattern.MatchString(input.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "color must be a hex color like #d73a4a"})
+		return
+	}
+	var description *string
+	if trimmed := strings.TrimSpace(input.Description); trimmed != "" {
+		description = &trimmed
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageLabels); !ok {
+		return
+	}
+
+	label, err := scanLabel(db.Pool.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO labels (project_id, name, color, description, created_by)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *
+		)
+		SELECT `+labelColumns+`
+		FROM inserted l
+	`, projectID, name, strings.ToLower(input.Color), description, user.RegistrationID))
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A label named " + name + " already exists in this project"})
+			return
+		}
+		logger.Log.Error("Failed to create label: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, label)
+}
+
+// UpdateLabel renames, recolors or re-describes a catalog label; bugs carrying it show the
+// change immediately. The label can be referenced by its UUID or its name. Requires the
+// manage labels permission (owner or maintainer).
+// Error responses: 400 (validation), 403 (role not allowed), 404 (not found),
+// 409 (name already used in the project / project archived), 500 (database error)
+func UpdateLabel(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateLabelRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+
+	// Build the SET clause from the fields that were sent.
+	// Column names are fixed strings; values are always passed as parameters.
+	sets := []string{}
+	args := []any{}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if input.Name != nil {
+		name, msg := normalizeLabelName(*input.Name)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		set("name", name)
+	}
+	if input.Color != nil {
+		if !labelColorPattern.MatchString(*input.Color) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "color must be a hex color like #d73a4a"})
+			return
+		}
+		set("color", strings.ToLower(*input.Color))
+	}
+	if input.Description != nil {
+		var description *string
+		if trimmed := strings.TrimSpace(*input.Description); trimmed != "" {
+			description = &trimmed
+		}
+		set("description", description)
+	}
+
+	if len(sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageLabels); !ok {
+		return
+	}
+
+	labelID, labelName := labelRefArgs(c.Param("labelId"))
+	args = append(args, projectID, labelID, labelName)
+	query := fmt.Sprintf(`
+		WITH updated AS (
+			UPDATE labels SET %s, updated_at = NOW()
+			WHERE project_id = $%d AND (id = $%d::UUID OR LOWER(name) = LOWER($%d))
+			RETURNING *
+		)
+		SELECT %s
+		FROM updated l
+	`, strings.Join(sets, ", "), len(args)-2, len(args)-1, len(args), labelColumns)
+
+	label, err := scanLabel(db.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+			return
+		}
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another label of this project already has this name"})
+			return
+		}
+		logger.Log.Error("Failed to update label: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel removes a label from the catalog and from every bug carrying it; each of
+// those bugs records the removal in its history. The label can be referenced by its UUID
+// or its name. Requires the manage labels permission (owner or maintainer).
+// Success response: 204 No Content
+// Error responses: 403 (role not allowed), 404 (not found), 409 (project archived), 500 (database error)
+func DeleteLabel(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageLabels); !ok {
+		return
+	}
+
+	// The deletion and the history of the affected bugs are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	labelID, labelName := labelRefArgs(c.Param("labelId"))
+	var id string
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM labels
+		WHERE project_id = $1 AND (id = $2::UUID OR LOWER(name) = LOWER($3))
+		FOR UPDATE
+	`, projectID, labelID, labelName).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+			return
+		}
+		logger.Log.Error("Failed to fetch label: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	// Lock the bugs carrying the label and capture their labels before the cascade
+	rows, err := tx.Query(ctx, `
+		SELECT b.id, b.bug_number
+		FROM bugs b
+		JOIN bug_labels bl ON bl.bug_id = b.id
+		WHERE bl.label_id = $1
+		ORDER BY b.id
+		FOR UPDATE OF b
+	`, id)
+	if err != nil {
+		logger.Log.Error("Failed to fetch labelled bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+	bugs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Bug, error) {
+		var b model.Bug
+		err := row.Scan(&b.ID, &b.BugNumber)
+		return b, err
+	})
+	if err == nil {
+		err = attachBugLabels(ctx, tx, bugs)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to fetch labelled bugs: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM labels WHERE id = $1`, id); err != nil {
+		logger.Log.Error("Failed to delete label: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	for _, b := range bugs {
+		after := slices.DeleteFunc(slices.Clone(b.Labels), func(l model.BugLabel) bool { return l.ID == id })
+		if err := saveBugLabelChange(ctx, tx, projectID, b.ID, b.BugNumber, user.RegistrationID, b.Labels, after); err != nil {
+			logger.Log.Error("Failed to record label removal: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit label deletion: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// lockBugForLabels locks the bug named by :bugRef for a label change and returns its
+// id, number and current labels. It writes the error response and returns false on failure.
+func lockBugForLabels(c *gin.Context, ctx context.Context, tx pgx.Tx, projectID string) (model.Bug, bool) {
+	bugID, bugNumber := bugRefArgs(strings.TrimSpace(c.Param("bugRef")))
+	var bug model.Bug
+	err := tx.QueryRow(ctx, `
+		SELECT id, bug_number FROM bugs
+		WHERE project_id = $1
+		AND (id = $2::UUID OR UPPER(bug_number) = UPPER($3))
+		FOR UPDATE
+	`, projectID, bugID, bugNumber).Scan(&bug.ID, &bug.BugNumber)
+	if err == nil {
+		bugs := []model.Bug{bug}
+		err = attachBugLabels(ctx, tx, bugs)
+		bug = bugs[0]
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+			return bug, false
+		}
+		logger.Log.Error("Failed to fetch bug: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return bug, false
+	}
+	return bug, true
+}
+
+// AddBugLabels applies labels from the project's catalog to a bug, by name. Labels the bug
+// already has are ignored. Requires the update bug permission (any project role except viewer).
+// Error responses: 400 (validation / too many labels), 403 (role not allowed), 404 (bug not found),
+// 409 (project archived), 422 (unknown label names), 500 (database error)
+func AddBugLabels(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.AddBugLabelsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	names, msg := normalizeLabelNames(input.Labels)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, _, ok := authorizeBug(c, ctx, user, permUpdateBug); !ok {
+		return
+	}
+	projectID := c.Param("id")
+
+	unknown, err := unknownLabels(ctx, projectID, names)
+	if err != nil {
+		logger.Log.Error("Failed to look up labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	if len(unknown) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unknown labels: " + strings.Join(unknown, ", ")})
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	bug, ok := lockBugForLabels(c, ctx, tx, projectID)
+	if !ok {
+		return
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO bug_labels (bug_id, label_id, added_by)
+		SELECT $1, id, $4 FROM labels WHERE project_id = $2 AND LOWER(name) = ANY($3)
+		ON CONFLICT (bug_id, label_id) DO NOTHING
+	`, bug.ID, projectID, lowerAll(names), user.RegistrationID)
+	if err != nil {
+		logger.Log.Error("Failed to add bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+
+	after := []model.Bug{{ID: bug.ID}}
+	if err := attachBugLabels(ctx, tx, after); err != nil {
+		logger.Log.Error("Failed to fetch bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	if len(after[0].Labels) > maxBugLabels {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A bug can have at most %d labels", maxBugLabels)})
+		return
+	}
+
+	if err := saveBugLabelChange(ctx, tx, projectID, bug.ID, bug.BugNumber, user.RegistrationID, bug.Labels, after[0].Labels); err != nil {
+		logger.Log.Error("Failed to record label change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+
+	labels := after[0].Labels
+	if labels == nil {
+		labels = []model.BugLabel{}
+	}
+	c.JSON(http.StatusOK, model.BugLabelsResponse{BugID: bug.ID, Labels: labels})
+}
+
+// RemoveBugLabel removes a label from a bug. The label can be referenced by its UUID or its
+// name. Requires the update bug permission (any project role except viewer).
+// Error responses: 403 (role not allowed), 404 (bug not found / label not on the bug),
+// 409 (project archived), 500 (database error)
+func RemoveBugLabel(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, _, ok := authorizeBug(c, ctx, user, permUpdateBug); !ok {
+		return
+	}
+	projectID := c.Param("id")
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	bug, ok := lockBugForLabels(c, ctx, tx, projectID)
+	if !ok {
+		return
+	}
+
+	labelRef := c.Param("labelId")
+	index := slices.IndexFunc(bug.Labels, func(l model.BugLabel) bool {
+		return l.ID == labelRef || strings.EqualFold(l.Name, labelRef)
+	})
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bug does not have this label"})
+		return
+	}
+	removed := bug.Labels[index]
+
+	if _, err := tx.Exec(ctx, `DELETE FROM bug_labels WHERE bug_id = $1 AND label_id = $2`, bug.ID, removed.ID); err != nil {
+		logger.Log.Error("Failed to remove bug label: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+
+	after := slices.Delete(slices.Clone(bug.Labels), index, index+1)
+	if err := saveBugLabelChange(ctx, tx, projectID, bug.ID, bug.BugNumber, user.RegistrationID, bug.Labels, after); err != nil {
+		logger.Log.Error("Failed to record label change: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit bug labels: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug labels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BugLabelsResponse{BugID: bug.ID, Labels: after})
+}
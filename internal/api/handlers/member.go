@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// projectMemberColumns selects a membership joined with the member's registration.
+// Expects project_members aliased as "m" and registrations as "r"; keep in sync with scanProjectMember.
+const projectMemberColumns = `m.project_id, m.user_id, r.full_name, r.email, m.role, m.assigned_at`
+
+// scanProjectMember reads a single row selected with projectMemberColumns.
+func scanProjectMember(row pgx.Row) (model.ProjectMember, error) {
+	var m model.ProjectMember
+	err := row.Scan(&m.ProjectID, &m.UserID, &m.FullName, &m.Email, &m.Role, &m.AssignedAt)
+	return m, err
+}
+
+// syncMemberCount recomputes projects.member_count from project_members.
+// Must run in the same transaction as the membership change.
+func syncMemberCount(ctx context.Context, tx pgx.Tx, projectID string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE projects
+		SET member_count = (SELECT COUNT(*) FROM project_members WHERE project_id = $1)
+		WHERE id = $1
+	`, projectID)
+	return err
+}
+
+// ListProjectMembers returns the members of a project.
+// Any user with access to the project (owner or member) can view its members.
+func ListProjectMembers(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+projectMemberColumns+`
+		FROM project_members m
+		JOIN registrations r ON r.id = m.user_id
+		WHERE m.project_id = $1
+		ORDER BY m.assigned_at, r.full_name
+	`, projectID)
+	if err != nil {
+		logger.Log.Error("Failed to query project members: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows (empty slice, not nil, for clean JSON [])
+	members := []model.ProjectMember{}
+	for rows.Next() {
+		m, err := scanProjectMember(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan project member row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+			return
+		}
+		members = append(members, m)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ProjectMemberListResponse{
+		Members: members,
+		Count:   len(members),
+	})
+}
+
+// AddProjectMember adds a registered user to a project with a project role.
+// Requires the manage members permission (owner or maintainer).
+// Error responses: 400 (validation), 403 (role not allowed), 404 (project or user not found),
+// 409 (already a member, the owner, or project archived), 500 (database error)
+func AddProjectMember(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.AddProjectMemberRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	if input.Role == "" {
+		input.Role = model.ProjectRoleDeveloper
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageMembers); !ok {
+		return
+	}
+
+	// The new member must be a registered user of the project's organisation
+	// and must not be the project owner
+	var isOwner bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT r.id = p.created_by
+		FROM registrations r, projects p
+		WHERE r.id = $1 AND p.id = $2 AND r.organisation_id = p.organisation_id
+	`, input.UserID, projectID).Scan(&isOwner)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		logger.Log.Error("Failed to look up user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	if isOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is the project owner"})
+		return
+	}
+
+	// Insert the membership and refresh member_count atomically
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	member, err := scanProjectMember(tx.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO project_members (project_id, user_id, role)
+			VALUES ($1, $2, $3)
+			RETURNING project_id, user_id, role, assigned_at
+		)
+		SELECT `+projectMemberColumns+`
+		FROM inserted m
+		JOIN registrations r ON r.id = m.user_id
+	`, projectID, input.UserID, input.Role))
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this project"})
+			return
+		}
+		logger.Log.Error("Failed to insert project member: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	if err := syncMemberCount(ctx, tx, projectID); err != nil {
+		logger.Log.Error("Failed to update member count: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityMemberAdded, nil, gin.H{
+		"user_id": member.UserID, "user_name": member.FullName, "role": member.Role,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit project member: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateProjectMember changes the project role of a member.
+// Requires the manage members permission (owner or maintainer).
+func UpdateProjectMember(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	memberID := c.Param("userId")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+	if _, err := uuid.Parse(memberID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateProjectMemberRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageMembers); !ok {
+		return
+	}
+
+	// The role change and its activity event are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	member, err := scanProjectMember(tx.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE project_members SET role = $3
+			WHERE project_id = $1 AND user_id = $2
+			RETURNING project_id, user_id, role, assigned_at
+		)
+		SELECT `+projectMemberColumns+`
+		FROM updated m
+		JOIN registrations r ON r.id = m.user_id
+	`, projectID, memberID, input.Role))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return
+		}
+		logger.Log.Error("Failed to update project member: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityMemberRoleChanged, nil, gin.H{
+		"user_id": member.UserID, "user_name": member.FullName, "role": member.Role,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit member update: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveProjectMember removes a member from a project and updates member_count.
+// Requires the manage members permission (owner or maintainer).
+// Success response: 204 No Content
+func RemoveProjectMember(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	memberID := c.Param("userId")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+	if _, err := uuid.Parse(memberID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permManageMembers); !ok {
+		return
+	}
+
+	// Delete the membership and refresh member_count atomically
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM project_members WHERE project_id = $1 AND user_id = $2`,
+		projectID, memberID,
+	)
+	if err != nil {
+		logger.Log.Error("Failed to delete project member: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if err := syncMemberCount(ctx, tx, projectID); err != nil {
+		logger.Log.Error("Failed to update member count: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityMemberRemoved, nil, gin.H{"user_id": memberID})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit member removal: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
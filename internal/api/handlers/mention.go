@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// querier is the part of pgx shared by the pool and transactions, for helpers that run
+// either inside or outside a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// mentionPattern matches "@handle" at the start of the text or after a character that
+// cannot be part of a word or an email address, so "a@b.com" is not a mention.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.])@([A-Za-z0-9][A-Za-z0-9._-]{0,99})`)
+
+// parseMentions returns the distinct handles mentioned in text (case-insensitive), in order
+// of appearance. Trailing punctuation ("@priya." at the end of a sentence) is not part of a handle.
+func parseMentions(text string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.TrimRight(match[1], ".-_")
+		if handle == "" || seen[strings.ToLower(handle)] {
+			continue
+		}
+		seen[strings.ToLower(handle)] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// mentionCandidate is a project member that mentions can resolve to.
+type mentionCandidate struct {
+	ID       string
+	FullName string
+	Email    string
+}
+
+// loadMentionCandidates returns the members of a project, the owner included.
+func loadMentionCandidates(ctx context.Context, q querier, projectID string) ([]mentionCandidate, error) {
+	rows, err := q.Query(ctx, `
+		SELECT r.id, r.full_name, r.email
+		FROM registrations r
+		WHERE r.id = (SELECT created_by FROM projects WHERE id = $1)
+		OR r.id IN (SELECT user_id FROM project_members WHERE project_id = $1)
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (mentionCandidate, error) {
+		var m mentionCandidate
+		err := row.Scan(&m.ID, &m.FullName, &m.Email)
+		return m, err
+	})
+}
+
+// compactName lowercases a name and drops separators, so "Priya Shah", "priya.shah"
+// and "priya_shah" compare equal.
+func compactName(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", ".", "", "_", "", "-", "").Replace(name))
+}
+
+/*
+resolveMention finds the project member a handle refers to. The rules are tried in order,
+and the first rule that matches exactly one member wins:
+ 1. the local part of the member's email ("@priya" for priya@example.com)
+ 2. the full name without separators ("@priya.shah" or "@PriyaShah" for "Priya Shah")
+ 3. the first name ("@priya" for "Priya Shah")
+
+A rule that matches several members makes the handle ambiguous, and it stays unresolved.
+*/
+func resolveMention(handle string, candidates []mentionCandidate) (string, bool) {
+	rules := []func(mentionCandidate) bool{
+		func(m mentionCandidate) bool {
+			local, _, _ := strings.Cut(m.Email, "@")
+			return strings.EqualFold(local, handle)
+		},
+		func(m mentionCandidate) bool {
+			return compactName(m.FullName) == compactName(handle)
+		},
+		func(m mentionCandidate) bool {
+			first, _, _ := strings.Cut(strings.TrimSpace(m.FullName), " ")
+			return strings.EqualFold(first, handle)
+		},
+	}
+	for _, rule := range rules {
+		var matches []string
+		for _, m := range candidates {
+			if rule(m) {
+				matches = append(matches, m.ID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], true
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+/*
+recordMentions parses the mentions in text (a bug description, or the comment commentID),
+resolves them against candidates and stores one inbox entry per mentioned member.
+Authors mentioning themselves get no entry, and members already mentioned in the same text
+are not mentioned again (so editing a comment only notifies newly mentioned members).
+It returns every parsed mention; unresolved ones are flagged for the caller's response.
+*/
+func recordMentions(ctx context.Context, q querier, candidates []mentionCandidate, bugID string, commentID *string, authorID, text string) ([]model.Mention, error) {
+	mentions := []model.Mention{}
+	for _, handle := range parseMentions(text) {
+		mention := model.Mention{Handle: handle}
+		if userID, ok := resolveMention(handle, candidates); ok {
+			mention.UserID = &userID
+			mention.Resolved = true
+			if userID != authorID {
+				_, err := q.Exec(ctx, `
+					INSERT INTO mentions (bug_id, comment_id, handle, mentioned_user_id, mentioned_by)
+					VALUES ($1, $2, $3, $4, $5)
+					ON CONFLICT DO NOTHING
+				`, bugID, commentID, handle, userID, authorID)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		mentions = append(mentions, mention)
+	}
+	return mentions, nil
+}
+
+// mentionInboxFilters restricts the inbox to the user's mentions in projects they can still
+// access, leaving out mentions in deleted comments. $1 is the user's registration ID.
+const mentionInboxFilters = `
+	FROM mentions m
+	JOIN bugs b ON b.id = m.bug_id
+	JOIN projects p ON p.id = b.project_id
+	JOIN registrations author ON author.id = m.mentioned_by
+	LEFT JOIN bug_comments cm ON cm.id = m.comment_id
+	WHERE m.mentioned_user_id = $1
+	AND (p.created_by = $1 OR EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = p.id AND pm.user_id = $1))
+	AND (m.comment_id IS NULL OR cm.deleted_at IS NULL)
+`
+
+// ListMyMentions returns the authenticated user's mention inbox, newest first.
+// Supports optional query parameters:
+//   - unread: true to list unread mentions only
+//   - page:   page number (default: 1)
+//   - limit:  items per page (default: 20, max: 100)
+func ListMyMentions(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Parse and validate pagination query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response := model.MentionInboxResponse{Page: page, Limit: limit}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE $2 = FALSE OR m.read_at IS NULL), COUNT(*) FILTER (WHERE m.read_at IS NULL)
+	`+mentionInboxFilters, user.RegistrationID, unreadOnly).Scan(&response.TotalCount, &response.UnreadCount)
+	if err != nil {
+		logger.Log.Error("Failed to count mentions: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT m.id, m.bug_id, b.bug_number, b.title, p.id, p.project_name, m.comment_id,
+		       LEFT(COALESCE(cm.body, b.description, ''), 200), m.mentioned_by, author.full_name,
+		       m.created_at, m.read_at
+	`+mentionInboxFilters+`
+		AND ($2 = FALSE OR m.read_at IS NULL)
+		ORDER BY m.created_at DESC, m.id
+		LIMIT $3 OFFSET $4
+	`, user.RegistrationID, unreadOnly, limit, offset)
+	if err != nil {
+		logger.Log.Error("Failed to query mentions: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows (empty slice, not nil, for clean JSON [])
+	response.Mentions = []model.MentionInboxItem{}
+	for rows.Next() {
+		var m model.MentionInboxItem
+		err := rows.Scan(
+			&m.ID, &m.BugID, &m.BugNumber, &m.BugTitle, &m.ProjectID, &m.ProjectName, &m.CommentID,
+			&m.Excerpt, &m.MentionedBy, &m.MentionedByName, &m.CreatedAt, &m.ReadAt,
+		)
+		if err != nil {
+			logger.Log.Error("Failed to scan mention row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+			return
+		}
+		response.Mentions = append(response.Mentions, m)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateMyMention marks one of the authenticated user's mentions as read or unread.
+// Error responses: 400 (validation), 404 (not found), 500 (database error)
+func UpdateMyMention(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	mentionID := c.Param("mentionId")
+	if _, err := uuid.Parse(mentionID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mention not found"})
+		return
+	}
+
+	// Bind and validate the JSON request body
+	var input model.UpdateMentionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Marking an already read mention as read keeps its original read_at
+	var readAt *time.Time
+	err := db.Pool.QueryRow(ctx, `
+		UPDATE mentions
+		SET read_at = CASE WHEN $3 THEN COALESCE(read_at, NOW()) END
+		WHERE id = $1 AND mentioned_user_id = $2
+		RETURNING read_at
+	`, mentionID, user.RegistrationID, *input.Read).Scan(&readAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Mention not found"})
+			return
+		}
+		logger.Log.Error("Failed to update mention: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mention"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": mentionID, "read_at": readAt})
+}
+
+// MarkAllMentionsRead marks every unread mention of the authenticated user as read and
+// returns how many were updated.
+func MarkAllMentionsRead(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag, err := db.Pool.Exec(ctx,
+		`UPDATE mentions SET read_at = NOW() WHERE mentioned_user_id = $1 AND read_at IS NULL`,
+		user.RegistrationID,
+	)
+	if err != nil {
+		logger.Log.Error("Failed to mark mentions read: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mentions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": tag.RowsAffected()})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrganisation returns the authenticated user's organisation with its user and project counts.
+func GetOrganisation(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var org model.Organisation
+	err := db.Pool.QueryRow(ctx, `
+		SELECT o.id, o.name, o.created_at,
+		       (SELECT COUNT(*) FROM registrations WHERE organisation_id = o.id),
+		       (SELECT COUNT(*) FROM projects WHERE organisation_id = o.id)
+		FROM organisations o
+		WHERE o.id = $1
+	`, user.OrganisationID).Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UserCount, &org.ProjectCount)
+	if err != nil {
+		logger.Log.Error("Failed to fetch organisation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organisation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// ListOrganisationUsers returns the users of the authenticated user's organisation,
+// including service accounts, ordered by name.
+// Supports an optional ?role= filter (e.g. "PM"; case-insensitive).
+func ListOrganisationUsers(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Optional role filter, normalised to the canonical spelling
+	role := optionalParam(c, "role")
+	if role != nil {
+		normalized, ok := model.NormalizeRole(*role)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be one of: Admin, PM, Developer, QA, Designer"})
+			return
+		}
+		role = &normalized
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+registrationColumns+`
+		FROM registrations r
+		JOIN organisations o ON o.id = r.organisation_id
+		WHERE r.organisation_id = $1
+		AND ($2::VARCHAR IS NULL OR r.role = $2)
+		ORDER BY r.full_name, r.created_at
+	`, user.OrganisationID, role)
+	if err != nil {
+		logger.Log.Error("Failed to query organisation users: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	defer rows.Close()
+
+	// Scan rows (empty slice, not nil, for clean JSON [])
+	users := []model.Registration{}
+	for rows.Next() {
+		u, err := scanRegistration(rows)
+		if err != nil {
+			logger.Log.Error("Failed to scan registration row: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
+		users = append(users, u)
+	}
+
+	if rows.Err() != nil {
+		logger.Log.Error("Row iteration error: " + rows.Err().Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.OrganisationUserListResponse{
+		Users: users,
+		Count: len(users),
+	})
+}
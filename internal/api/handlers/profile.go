@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// loadProfile returns the profile of a registration with its counts.
+func loadProfile(ctx context.Context, registrationID string) (model.Profile, error) {
+	var p model.Profile
+	err := db.Pool.QueryRow(ctx, `
+		SELECT `+registrationColumns+`, r.avatar_url, r.timezone, r.notification_preferences,
+		       (SELECT COUNT(*) FROM projects WHERE created_by = r.id),
+		       (SELECT COUNT(*) FROM project_members WHERE user_id = r.id),
+		       (SELECT COUNT(*) FROM bugs WHERE assigned_to = r.id AND status IN ('open', 'in_progress')),
+		       (SELECT COUNT(*) FROM bugs WHERE created_by = r.id)
+		FROM registrations r
+		JOIN organisations o ON o.id = r.organisation_id
+		WHERE r.id = $1
+	`, registrationID).Scan(
+		&p.ID, &p.FullName, &p.Email, &p.OrganisationID, &p.OrganisationName,
+		&p.Role, &p.SupabaseUserID, &p.IsServiceAccount, &p.CreatedAt,
+		&p.AvatarURL, &p.Timezone, &p.NotificationPreferences,
+		&p.Stats.OwnedProjects, &p.Stats.Memberships, &p.Stats.OpenAssignedBugs, &p.Stats.ReportedBugs,
+	)
+	return p, err
+}
+
+// GetMe returns the authenticated user's registration and profile with counts of owned
+// projects, memberships, open assigned bugs and reported bugs.
+func GetMe(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// 5-second timeout for the database query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile, err := loadProfile(ctx, user.RegistrationID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch profile: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateMe applies a partial update to the authenticated user's profile (full_name,
+// avatar_url, timezone, notification_preferences) and returns the updated profile.
+// Role and email cannot be changed here.
+// Error responses: 400 (validation / role or email sent / nothing to update), 500 (database error)
+func UpdateMe(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// Bind and validate the JSON request body against model.UpdateProfileRequest rules
+	var input model.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationMessage(err, input)})
+		return
+	}
+	if input.Role != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role cannot be changed here; ask an administrator"})
+		return
+	}
+	if input.Email != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email cannot be changed; it comes from your login"})
+		return
+	}
+
+	// Build the SET clause from the fields that were sent.
+	// Column names are fixed strings; values are always passed as parameters.
+	sets := []string{}
+	args := []any{}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if input.FullName != nil {
+		name := strings.TrimSpace(*input.FullName)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "full_name cannot be empty"})
+			return
+		}
+		set("full_name", name)
+	}
+	if input.AvatarURL != nil {
+		// An empty string removes the avatar; anything else must be an absolute http(s) URL
+		var avatarURL *string
+		if trimmed := strings.TrimSpace(*input.AvatarURL); trimmed != "" {
+			parsed, err := url.Parse(trimmed)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid avatar_url. Use an http or https URL"})
+				return
+			}
+			avatarURL = &trimmed
+		}
+		set("avatar_url", avatarURL)
+	}
+	if input.Timezone != nil {
+		// "Local" would mean the server's zone, which is meaningless to clients
+		if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "Local" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone. Use an IANA name such as Europe/Berlin"})
+			return
+		}
+		set("timezone", *input.Timezone)
+	}
+	if input.NotificationPreferences != nil {
+		// Merge into the stored preferences so omitted keys keep their value
+		patch, _ := json.Marshal(input.NotificationPreferences) // Cannot fail for a struct of *bool
+		args = append(args, string(patch))
+		sets = append(sets, fmt.Sprintf("notification_preferences = notification_preferences || $%d::JSONB", len(args)))
+	}
+
+	if len(sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args = append(args, user.RegistrationID)
+	query := fmt.Sprintf(`UPDATE registrations SET %s WHERE id = $%d`, strings.Join(sets, ", "), len(args))
+	if _, err := db.Pool.Exec(ctx, query, args...); err != nil {
+		logger.Log.Error("Failed to update profile: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	profile, err := loadProfile(ctx, user.RegistrationID)
+	if err != nil {
+		logger.Log.Error("Failed to fetch profile: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
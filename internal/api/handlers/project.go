@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
 	"github.com/Ankit1974/TaskDeskBackend/internal/model"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 // generateWorkspaceID creates a short, human-readable workspace identifier.
@@ -34,8 +37,39 @@ func generateWorkspaceID(projectName string) string {
 	return fmt.Sprintf("%s-%s", prefix, suffix)
 }
 
-// CreateProject handles project creation. Only accessible by users with the "PM" role.
-// Error responses: 400 (validation), 401 (unauthenticated), 403 (not PM), 500 (database error)
+// projectColumns is the column list shared by every query that returns full project rows.
+// Keep it in sync with scanProject.
+const projectColumns = `id, project_name, description, icon, teams, start_date, status, workspace_id, bug_prefix, organisation_id, created_by, progress, member_count, archived_at, created_at, updated_at`
+
+// scanProject reads a single row selected with projectColumns into a model.Project.
+func scanProject(row pgx.Row) (model.Project, error) {
+	var p model.Project
+	var startDate *time.Time
+
+	err := row.Scan(
+		&p.ID, &p.ProjectName, &p.Description, &p.Icon, &p.Teams,
+		&startDate, &p.Status, &p.WorkspaceID, &p.BugPrefix, &p.OrganisationID, &p.CreatedBy,
+		&p.Progress, &p.MemberCount, &p.ArchivedAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+
+	// Convert *time.Time to *string for the response (YYYY-MM-DD format)
+	if startDate != nil {
+		formatted := startDate.Format("2006-01-02")
+		p.StartDate = &formatted
+	}
+	if p.Teams == nil {
+		p.Teams = []string{}
+	}
+	return p, err
+}
+
+// bugPrefixPattern restricts project bug prefixes to short uppercase identifiers
+// such as "BUG" or "APP2" (mirrors chk_bug_prefix in the database).
+var bugPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}$`)
+
+// CreateProject handles project creation. Only accessible by users with the "PM" or "Admin" role.
+// The project belongs to the creator's organisation.
+// Error responses: 400 (validation), 401 (unauthenticated), 403 (not PM/Admin), 500 (database error)
 func CreateProject(c *gin.Context) {
 	// Get the authenticated user (set by AuthMiddleware)
 	user := middleware.GetUser(c)
@@ -51,6 +85,16 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	// Optional bug number prefix (default "BUG", producing "BUG-1", "BUG-2", ...)
+	bugPrefix := strings.ToUpper(strings.TrimSpace(input.BugPrefix))
+	if bugPrefix == "" {
+		bugPrefix = "BUG"
+	}
+	if !bugPrefixPattern.MatchString(bugPrefix) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug_prefix. Use 1-10 letters or digits, starting with a letter"})
+		return
+	}
+
 	// Generate a unique workspace identifier for the project
 	workspaceID := generateWorkspaceID(input.ProjectName)
 
@@ -71,8 +115,8 @@ func CreateProject(c *gin.Context) {
 
 	// Insert the project with default status "planning", progress 0, member_count 0.
 	query := `
-		INSERT INTO projects (project_name, description, teams, start_date, status, workspace_id, created_by)
-		VALUES ($1, $2, $3, $4, 'planning', $5, $6)
+		INSERT INTO projects (project_name, description, teams, start_date, status, workspace_id, bug_prefix, organisation_id, created_by)
+		VALUES ($1, $2, $3, $4, 'planning', $5, $6, $7, $8)
 		RETURNING id, status, progress, member_count, created_at, updated_at
 	`
 
@@ -86,16 +130,29 @@ func CreateProject(c *gin.Context) {
 		project.Teams = []string{} // Ensure JSON serializes as [] instead of null
 	}
 	project.WorkspaceID = workspaceID
+	project.BugPrefix = bugPrefix
+	project.OrganisationID = user.OrganisationID
 	project.CreatedBy = user.RegistrationID
 
+	// The project and its first activity event are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
 	// Execute the insert and scan the returned auto-generated fields
-	err := db.Pool.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		input.ProjectName,
 		input.Description,
 		//input.Icon,
 		input.Teams, // pgx natively converts []string to PostgreSQL TEXT[]
 		startDate,   // nil becomes SQL NULL for optional dates
 		workspaceID,
+		bugPrefix,
+		user.OrganisationID,
 		user.RegistrationID, // The PM's registration UUID
 	).Scan(
 		&project.ID,
@@ -112,6 +169,20 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	err = recordActivity(ctx, tx, project.ID, user.RegistrationID, model.ActivityProjectCreated, nil, gin.H{
+		"project_name": project.ProjectName,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit project: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
 	// Set the start_date string in the response (only if provided by the client)
 	if input.StartDate != "" {
 		project.StartDate = &input.StartDate
@@ -120,10 +191,11 @@ func CreateProject(c *gin.Context) {
 	c.JSON(http.StatusCreated, project)
 }
 
-// GetProjects returns all projects created by or assigned to the authenticated user.
+// GetProjects returns all projects of the user's organisation that they created or are a member of.
 // Supports optional query parameters:
 //   - status: filter by project status (active, planning, on_hold, completed)
 //   - search: case-insensitive search on project_name
+//   - include_archived: also return archived projects (default: false)
 //   - page:   page number (default: 1)
 //   - limit:  items per page (default: 10, max: 50)
 func GetProjects(c *gin.Context) {
@@ -168,6 +240,9 @@ func GetProjects(c *gin.Context) {
 		searchParam = &search
 	}
 
+	// Archived projects are hidden unless explicitly requested
+	includeArchived, _ := strconv.ParseBool(c.DefaultQuery("include_archived", "false"))
+
 	// 5-second timeout for database queries
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -181,13 +256,15 @@ func GetProjects(c *gin.Context) {
 			UNION
 			SELECT project_id FROM project_members WHERE user_id = $1
 		)
+		AND p.organisation_id = $5
 		AND ($2::VARCHAR IS NULL OR p.status = $2)
 		AND ($3::VARCHAR IS NULL OR p.project_name ILIKE '%' || $3 || '%')
+		AND ($4::BOOLEAN OR p.archived_at IS NULL)
 	`
 
 	var totalCount int
 	err := db.Pool.QueryRow(ctx, countQuery,
-		user.RegistrationID, statusParam, searchParam,
+		user.RegistrationID, statusParam, searchParam, includeArchived, user.OrganisationID,
 	).Scan(&totalCount)
 	if err != nil {
 		logger.Log.Error("Failed to count projects: " + err.Error())
@@ -197,23 +274,23 @@ func GetProjects(c *gin.Context) {
 
 	// Fetch the paginated project list ordered by most recently updated
 	dataQuery := `
-		SELECT p.id, p.project_name, p.description, p.icon, p.teams,
-		       p.start_date, p.status, p.workspace_id, p.created_by,
-		       p.progress, p.member_count, p.created_at, p.updated_at
+		SELECT ` + prefixColumns("p", projectColumns) + `
 		FROM projects p
 		WHERE p.id IN (
 			SELECT id FROM projects WHERE created_by = $1
 			UNION
 			SELECT project_id FROM project_members WHERE user_id = $1
 		)
+		AND p.organisation_id = $7
 		AND ($2::VARCHAR IS NULL OR p.status = $2)
 		AND ($3::VARCHAR IS NULL OR p.project_name ILIKE '%' || $3 || '%')
+		AND ($4::BOOLEAN OR p.archived_at IS NULL)
 		ORDER BY p.updated_at DESC
-		LIMIT $4 OFFSET $5
+		LIMIT $5 OFFSET $6
 	`
 
 	rows, err := db.Pool.Query(ctx, dataQuery,
-		user.RegistrationID, statusParam, searchParam, limit, offset,
+		user.RegistrationID, statusParam, searchParam, includeArchived, limit, offset, user.OrganisationID,
 	)
 	if err != nil {
 		logger.Log.Error("Failed to query projects: " + err.Error())
@@ -225,29 +302,13 @@ func GetProjects(c *gin.Context) {
 	// Scan rows into Project structs (empty slice, not nil, for clean JSON [])
 	projects := []model.Project{}
 	for rows.Next() {
-		var p model.Project
-		var startDate *time.Time
-
-		err := rows.Scan(
-			&p.ID, &p.ProjectName, &p.Description, &p.Icon, &p.Teams,
-			&startDate, &p.Status, &p.WorkspaceID, &p.CreatedBy,
-			&p.Progress, &p.MemberCount, &p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scanProject(rows)
 		if err != nil {
 			logger.Log.Error("Failed to scan project row: " + err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
 			return
 		}
 
-		// Convert *time.Time to *string for the response (YYYY-MM-DD format)
-		if startDate != nil {
-			formatted := startDate.Format("2006-01-02")
-			p.StartDate = &formatted
-		}
-		if p.Teams == nil {
-			p.Teams = []string{}
-		}
-
 		projects = append(projects, p)
 	}
 
@@ -266,7 +327,7 @@ func GetProjects(c *gin.Context) {
 }
 
 // GetProjectByID returns the full details of a single project.
-// The user must be the project creator or an assigned member to view it.
+// Any project role can view it; other users get 404.
 func GetProjectByID(c *gin.Context) {
 	// Get the authenticated user (set by AuthMiddleware)
 	user := middleware.GetUser(c)
@@ -285,30 +346,15 @@ func GetProjectByID(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Fetch the project only if the user is the creator or an assigned member
-	query := `
-		SELECT p.id, p.project_name, p.description, p.icon, p.teams,
-		       p.start_date, p.status, p.workspace_id, p.created_by,
-		       p.progress, p.member_count, p.created_at, p.updated_at
-		FROM projects p
-		WHERE p.id = $1
-		AND p.id IN (
-			SELECT id FROM projects WHERE created_by = $2
-			UNION
-			SELECT project_id FROM project_members WHERE user_id = $2
-		)
-	`
+	if _, ok := authorizeProject(c, ctx, projectID, user, permViewProject); !ok {
+		return
+	}
 
-	var project model.Project
-	var startDate *time.Time
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
 
-	err := db.Pool.QueryRow(ctx, query, projectID, user.RegistrationID).Scan(
-		&project.ID, &project.ProjectName, &project.Description, &project.Icon, &project.Teams,
-		&startDate, &project.Status, &project.WorkspaceID, &project.CreatedBy,
-		&project.Progress, &project.MemberCount, &project.CreatedAt, &project.UpdatedAt,
-	)
+	project, err := scanProject(db.Pool.QueryRow(ctx, query, projectID))
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 			return
 		}
@@ -317,13 +363,141 @@ func GetProjectByID(c *gin.Context) {
 		return
 	}
 
-	// Convert *time.Time to *string for the response (YYYY-MM-DD format)
-	if startDate != nil {
-		formatted := startDate.Format("2006-01-02")
-		project.StartDate = &formatted
+	c.JSON(http.StatusOK, project)
+}
+
+// UpdateProject applies a partial update to a project. Only the fields present in the
+// request body are changed, and updated_at is bumped so the project moves to the top of
+// the GetProjects listing. Requires the edit project permission (owner or maintainer).
+// Error responses: 400 (validation), 403 (role not allowed), 404 (not found / no access),
+// 409 (project is archived), 500 (database error)
+func UpdateProject(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
 	}
-	if project.Teams == nil {
-		project.Teams = []string{}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// Bind and validate the JSON request body against model.UpdateProjectRequest rules
+	var input model.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Build the SET clause from the fields that were sent.
+	// Column names are fixed strings; values are always passed as parameters.
+	sets := []string{}
+	args := []any{}
+	fields := []string{} // Changed fields, for the activity feed
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+		fields = append(fields, column)
+	}
+
+	if input.ProjectName != nil {
+		name := strings.TrimSpace(*input.ProjectName)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "project_name cannot be empty"})
+			return
+		}
+		set("project_name", name)
+	}
+	if input.Description != nil {
+		set("description", *input.Description)
+	}
+	if input.Icon != nil {
+		set("icon", *input.Icon)
+	}
+	if input.Teams != nil {
+		set("teams", *input.Teams) // pgx natively converts []string to PostgreSQL TEXT[]
+	}
+	if input.StartDate != nil {
+		// An empty string clears the date; anything else must be YYYY-MM-DD
+		var startDate *time.Time
+		if *input.StartDate != "" {
+			parsed, err := time.Parse("2006-01-02", *input.StartDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+				return
+			}
+			startDate = &parsed
+		}
+		set("start_date", startDate)
+	}
+	if input.Status != nil {
+		set("status", *input.Status)
+	}
+	if input.BugPrefix != nil {
+		// Existing bugs keep their numbers; new bugs continue the same sequence with the new prefix
+		bugPrefix := strings.ToUpper(strings.TrimSpace(*input.BugPrefix))
+		if !bugPrefixPattern.MatchString(bugPrefix) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug_prefix. Use 1-10 letters or digits, starting with a letter"})
+			return
+		}
+		set("bug_prefix", bugPrefix)
+	}
+
+	if len(sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permEditProject); !ok {
+		return
+	}
+
+	// Archived projects are read-only. authorizeProject already rejected them, but the
+	// project may have been archived since, so "no rows" here means it is archived.
+	args = append(args, projectID)
+	query := fmt.Sprintf(`
+		UPDATE projects SET %s, updated_at = NOW()
+		WHERE id = $%d AND archived_at IS NULL
+		RETURNING %s
+	`, strings.Join(sets, ", "), len(args), projectColumns)
+
+	// The update and its activity event are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	project, err := scanProject(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Project is archived. Restore it before editing"})
+			return
+		}
+		logger.Log.Error("Failed to update project: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	err = recordActivity(ctx, tx, projectID, user.RegistrationID, model.ActivityProjectUpdated, nil, gin.H{"fields": fields})
+	if err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit project update: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
 	}
 
 	c.JSON(http.StatusOK, project)
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/db"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ArchiveProject soft-deletes a project by setting archived_at. Archived projects are hidden
+// from GetProjects (unless ?include_archived=true), cannot be edited and do not accept new
+// bugs, but all their data is kept. Requires the archive permission (owner or maintainer).
+// Error responses: 403 (role not allowed), 404 (not found / no access), 409 (already archived), 500
+func ArchiveProject(c *gin.Context) {
+	setProjectArchived(c, true)
+}
+
+// RestoreProject clears archived_at, making an archived project active again.
+// Requires the archive permission (owner or maintainer).
+// Error responses: 403 (role not allowed), 404 (not found / no access), 409 (not archived), 500
+func RestoreProject(c *gin.Context) {
+	setProjectArchived(c, false)
+}
+
+// setProjectArchived implements ArchiveProject and RestoreProject.
+func setProjectArchived(c *gin.Context, archive bool) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	query := `
+		UPDATE projects SET archived_at = NULL, archived_by = NULL, updated_at = NOW()
+		WHERE id = $1 AND archived_at IS NOT NULL
+		RETURNING ` + projectColumns
+	conflict := "Project is not archived"
+	event := model.ActivityProjectRestored
+	if archive {
+		query = `
+			UPDATE projects SET archived_at = NOW(), archived_by = $2, updated_at = NOW()
+			WHERE id = $1 AND archived_at IS NULL
+			RETURNING ` + projectColumns
+		conflict = "Project is already archived"
+		event = model.ActivityProjectArchived
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permArchiveProject); !ok {
+		return
+	}
+
+	args := []any{projectID}
+	if archive {
+		args = append(args, user.RegistrationID)
+	}
+
+	// The state change and its activity event are saved together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	// The access check proved the project exists, so "no rows" means it is already in the target state
+	project, err := scanProject(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": conflict})
+			return
+		}
+		logger.Log.Error("Failed to update project archive state: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	if err := recordActivity(ctx, tx, projectID, user.RegistrationID, event, nil, nil); err != nil {
+		logger.Log.Error("Failed to record activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit project archive state: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// PurgeProject permanently deletes an archived project together with its bugs and memberships
+// (via ON DELETE CASCADE). It requires the global PM role and the project archive permission
+// (owner or maintainer), and the project must have been archived for at least
+// PROJECT_PURGE_RETENTION_DAYS days.
+// Success response: 204 No Content
+// Error responses: 403 (role not allowed), 404 (not found / no access), 409 (not archived or
+// retention window not over), 500 (database error)
+func PurgeProject(c *gin.Context) {
+	// Get the authenticated user (set by AuthMiddleware)
+	user := middleware.GetUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project ID is required"})
+		return
+	}
+
+	// 5-second timeout for all database operations
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := authorizeProject(c, ctx, projectID, user, permArchiveProject); !ok {
+		return
+	}
+
+	var archivedAt *time.Time
+	err := db.Pool.QueryRow(ctx, `SELECT archived_at FROM projects WHERE id = $1`, projectID).Scan(&archivedAt)
+	if err != nil {
+		logger.Log.Error("Failed to fetch project: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge project"})
+		return
+	}
+	if archivedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only archived projects can be purged"})
+		return
+	}
+
+	retention := time.Duration(config.Cfg.ProjectPurgeRetentionDays) * 24 * time.Hour
+	if purgeAfter := archivedAt.Add(retention); time.Now().Before(purgeAfter) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Project can be purged after %s (%d-day retention)",
+				purgeAfter.UTC().Format(time.RFC3339), config.Cfg.ProjectPurgeRetentionDays),
+		})
+		return
+	}
+
+	// Collect the stored attachment content before the bugs' rows cascade away.
+	// Archived projects accept no uploads, so the list cannot grow meanwhile.
+	var storageKeys []string
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ba.storage_key FROM bug_attachments ba
+		JOIN bugs b ON b.id = ba.bug_id
+		WHERE b.project_id = $1
+	`, projectID)
+	if err == nil {
+		storageKeys, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	}
+	if err != nil {
+		logger.Log.Error("Failed to fetch project attachments: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge project"})
+		return
+	}
+
+	// Re-check the archive state in the DELETE itself in case the project was restored meanwhile
+	tag, err := db.Pool.Exec(ctx, `
+		DELETE FROM projects
+		WHERE id = $1 AND archived_at IS NOT NULL AND archived_at <= NOW() - make_interval(days => $2)
+	`, projectID, config.Cfg.ProjectPurgeRetentionDays)
+	if err != nil {
+		logger.Log.Error("Failed to purge project: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge project"})
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project was restored and can no longer be purged"})
+		return
+	}
+
+	deleteBlobs(storageKeys)
+	logger.Log.Info(fmt.Sprintf("Project %s purged by %s", projectID, user.RegistrationID))
+	c.Status(http.StatusNoContent)
+}
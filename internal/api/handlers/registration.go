@@ -3,20 +3,54 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
 	"github.com/Ankit1974/TaskDeskBackend/internal/db"
 	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
 	"github.com/Ankit1974/TaskDeskBackend/internal/model"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
+// registrationColumns selects a registration joined with its organisation.
+// Expects registrations aliased as "r" and organisations as "o"; keep in sync with scanRegistration.
+const registrationColumns = `r.id, r.full_name, r.email, r.organisation_id, o.name, r.role, COALESCE(r.supabase_user_id::TEXT, ''), r.is_service_account, r.created_at`
+
+// scanRegistration reads a single row selected with registrationColumns.
+func scanRegistration(row pgx.Row) (model.Registration, error) {
+	var r model.Registration
+	err := row.Scan(
+		&r.ID, &r.FullName, &r.Email, &r.OrganisationID, &r.OrganisationName,
+		&r.Role, &r.SupabaseUserID, &r.IsServiceAccount, &r.CreatedAt,
+	)
+	return r, err
+}
+
 /*
 It validates the request body, inserts the user into the registrations table,
 Success response: 201 Created with the full registration recsord
-Error responses: 400 (validation), 500 (database error)
+Error responses: 400 (validation / email mismatch), 401 (missing or invalid JWT),
+409 (already registered / organisation name taken), 500 (database error)
+
+Registration requires a valid Supabase JWT (see middleware.RequireToken): the registration is
+bound to the token's "sub" claim and uses the token's email, so the two identities are tied
+together at signup.
+
+Registering creates a new organisation named organisation_name, and the registering user
+becomes its Admin (a role in the body is ignored). Names that are already taken are
+rejected: existing organisations can only be joined by invitation, so nobody can place
+themselves in another company's tenant.
 */
 func Register(c *gin.Context) {
+	// Get the verified Supabase identity (set by RequireToken)
+	identity := middleware.GetTokenIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
 	// Bind and validate the JSON request body against model.Registration rules
 	var input model.Registration
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -24,29 +58,75 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	// The email always comes from the token; a different email in the body is a client error
+	if identity.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token missing email claim"})
+		return
+	}
+	if input.Email != "" && !strings.EqualFold(input.Email, identity.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email does not match the authenticated user"})
+		return
+	}
+	organisationName := strings.TrimSpace(input.OrganisationName)
+	if organisationName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "organisation_name is required"})
+		return
+	}
+
 	// 5-second timeout to prevent long-running DB queries from blocking
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Insert the new registration and return the auto-generated ID and created_at
-	query := `
-		INSERT INTO registrations (full_name, email, organisation_name, role)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
-	`
+	// The organisation and its founding Admin are created together
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to begin transaction: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
+		return
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
 
-	err := db.Pool.QueryRow(ctx, query,
-		input.FullName,
-		input.Email,
-		input.OrganisationName,
-		input.Role,
-	).Scan(&input.ID, &input.CreatedAt)
+	var organisationID string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO organisations (name) VALUES ($1) RETURNING id`,
+		organisationName,
+	).Scan(&organisationID)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "An organisation with this name already exists. Ask one of its administrators for an invitation"})
+			return
+		}
+		logger.Log.Error("Failed to insert organisation: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
+		return
+	}
 
+	// Insert the new registration and return it with its organisation
+	registration, err := scanRegistration(tx.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO registrations (full_name, email, organisation_id, role, supabase_user_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, full_name, email, organisation_id, role, supabase_user_id, is_service_account, created_at
+		)
+		SELECT `+registrationColumns+`
+		FROM inserted r
+		JOIN organisations o ON o.id = r.organisation_id
+	`, input.FullName, identity.Email, organisationID, model.RoleAdmin, identity.SupabaseUserID))
 	if err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already registered"})
+			return
+		}
 		logger.Log.Error("Failed to insert registration: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, input)
+	if err := tx.Commit(ctx); err != nil {
+		logger.Log.Error("Failed to commit registration: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, registration)
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validationMessage turns a binding/validator error into a short, client-friendly message
+// that uses the JSON field names of obj (e.g. "title is required; priority must be one of
+// [critical high medium low]"). Errors that are not validation errors are returned as-is.
+func validationMessage(err error, obj any) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err.Error()
+	}
+
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	messages := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		name := fe.Field()
+		if field, ok := t.FieldByName(fe.StructField()); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				name = tag
+			}
+		}
+
+		switch fe.Tag() {
+		case "required":
+			messages = append(messages, name+" is required")
+		case "oneof":
+			messages = append(messages, fmt.Sprintf("%s must be one of [%s]", name, fe.Param()))
+		case "max":
+			messages = append(messages, fmt.Sprintf("%s must be at most %s characters", name, fe.Param()))
+		case "min":
+			messages = append(messages, fmt.Sprintf("%s must be at least %s characters", name, fe.Param()))
+		case "uuid":
+			messages = append(messages, name+" must be a UUID")
+		case "email":
+			messages = append(messages, name+" must be a valid email address")
+		default:
+			messages = append(messages, fmt.Sprintf("%s failed on the '%s' rule", name, fe.Tag()))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
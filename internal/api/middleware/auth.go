@@ -3,16 +3,17 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/Ankit1974/TaskDeskBackend/internal/config"
 	"github.com/Ankit1974/TaskDeskBackend/internal/db"
 	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 /*
@@ -25,74 +26,179 @@ type UserContext struct {
 	Email          string // User's email address (from JWT "email" claim)
 	RegistrationID string // Primary key from the registrations table
 	Role           string // User's role from registrations (e.g., "PM", "Developer")
+	OrganisationID string // The user's organisation; all project data is scoped to it
+
+	// Set only when the request was authenticated with an API token instead of a JWT
+	APITokenID     string   // api_tokens.id
+	Scopes         []string // Scopes granted to the token (e.g., "bugs:write")
+	TokenProjectID string   // Project the token is restricted to ("" = any accessible project)
+	scopeGranted   bool     // Set by RequireScope once the route has authorised the token
 }
 
 // UserContextKey is the key used to store/retrieve UserContext in the Gin context.
 const UserContextKey = "user"
 
+// TokenIdentity holds the identity claims of a validated Supabase JWT, before (or without)
+// any registrations lookup. It is stored in the Gin context by RequireToken.
+type TokenIdentity struct {
+	SupabaseUserID string // Supabase auth.users UUID (from JWT "sub" claim)
+	Email          string // User's email address (from JWT "email" claim)
+}
+
+// TokenIdentityKey is the key used to store/retrieve TokenIdentity in the Gin context.
+const TokenIdentityKey = "token_identity"
+
+// bearerCredential extracts the credential from a "Bearer <credential>" Authorization header.
+// On failure it aborts the request with 401 and returns ok=false.
+func bearerCredential(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid authorization header"})
+		return "", false
+	}
+	return strings.TrimPrefix(authHeader, "Bearer "), true
+}
+
+// parseBearerToken validates a Supabase JWT and extracts its identity claims.
+// On failure it aborts the request with 401 and returns ok=false.
+func parseBearerToken(c *gin.Context, tokenString string) (identity TokenIdentity, ok bool) {
+	// Verification is configured once at startup by InitAuth
+	if tokenParser == nil {
+		logger.Log.Error("Auth middleware: InitAuth was not called")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Authentication is not configured"})
+		return identity, false
+	}
+
+	// Parse and validate the JWT: signature (HMAC secret or JWKS key), expiry, iss and aud
+	token, err := tokenParser.Parse(tokenString, verificationKey)
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return identity, false
+	}
+
+	// Extract claims from the validated token
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return identity, false
+	}
+
+	identity.SupabaseUserID, _ = claims["sub"].(string) // Supabase user UUID
+	identity.Email, _ = claims["email"].(string)        // User email from Supabase auth
+
+	// The subject is the stable identity registrations are bound to
+	if _, err := uuid.Parse(identity.SupabaseUserID); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token missing or invalid sub claim"})
+		return identity, false
+	}
+	return identity, true
+}
+
 /*
-	AuthMiddleware validates the Supabase JWT from the Authorization header
+RequireToken validates the Supabase JWT but does not require a registration.
+It is used by endpoints such as POST /register that tie a Supabase identity to a
+new registration. Handlers read the identity via GetTokenIdentity().
+*/
+func RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerCredential(c)
+		if !ok {
+			return
+		}
+		identity, ok := parseBearerToken(c, tokenString)
+		if !ok {
+			return
+		}
+		c.Set(TokenIdentityKey, &identity)
+		c.Next()
+	}
+}
+
+/*
+	AuthMiddleware validates the Supabase JWT (or API token) from the Authorization header
 	and loads the user's registration data from the database.
 */
 /*
 	Flow:
 	  1. Extract "Bearer <token>" from the Authorization header
-	  2. Parse and validate the JWT using the Supabase JWT secret (HMAC-SHA256)
-	  3. Extract the "email" claim from the token
-	  4. Query the registrations table to get the user's ID and role
+	  2. Parse and validate the JWT (HS256 with the Supabase JWT secret, or RS256/ES256
+	     against the configured JWKS), including the optional iss/aud checks
+	  3. Extract the "sub" (and "email") claims from the token
+	  4. Query the registrations table by supabase_user_id to get the user's ID, role and organisation
+	     (served from the in-process cache when enabled; see userCache).
+	     Registrations created before sub binding are linked once, by email.
 	  5. Store the UserContext in Gin's context for downstream handlers
+
+	API tokens ("tdk_...") replace steps 2–4 with a lookup of the token's hash in
+	api_tokens and produce the same UserContext, plus the token's scopes. They are
+	only usable on routes that opt in with RequireScope.
 */
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Step 1: Extract the Bearer token from the Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid authorization header"})
+		// Step 1: Extract the bearer credential
+		tokenString, ok := bearerCredential(c)
+		if !ok {
 			return
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		// Step 2: Parse and validate the JWT using Supabase's signing secret
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Ensure the token uses HMAC signing (Supabase uses HS256)
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// Personal access tokens and service-account keys
+		if isAPIToken(tokenString) {
+			userCtx, ok := authenticateAPIToken(c, tokenString)
+			if !ok {
+				return
 			}
-			return []byte(config.Cfg.SupabaseJWTSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Set(UserContextKey, userCtx)
+			c.Next()
 			return
 		}
 
-		// Step 3: Extract claims from the validated token
-		claims, ok := token.Claims.(jwt.MapClaims)
+		// Steps 2–3: Validate and read the JWT
+		identity, ok := parseBearerToken(c, tokenString)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			return
 		}
 
-		supabaseUserID, _ := claims["sub"].(string) // Supabase user UUID
-		email, _ := claims["email"].(string)        // User email from Supabase auth
+		// Step 4: Look up the user (cache first, then the registrations table) to get their role
+		if authCache != nil {
+			if cached, ok := authCache.get(identity.SupabaseUserID); ok {
+				cached.Email = identity.Email
+				c.Set(UserContextKey, &cached)
+				c.Next()
+				return
+			}
+		}
 
-		if email == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token missing email claim"})
-			return
+		// Read before the lookup, so that a concurrent invalidation prevents caching the result
+		var generation uint64
+		if authCache != nil {
+			generation = authCache.currentGeneration()
 		}
 
-		// Step 4: Look up the user in the registrations table to get their role
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		var userCtx UserContext
-		userCtx.SupabaseUserID = supabaseUserID
-		userCtx.Email = email
+		userCtx.SupabaseUserID = identity.SupabaseUserID
+		userCtx.Email = identity.Email
 
-		err = db.Pool.QueryRow(ctx,
-			`SELECT id, role FROM registrations WHERE email = $1`,
-			email,
-		).Scan(&userCtx.RegistrationID, &userCtx.Role)
+		err := db.Pool.QueryRow(ctx,
+			`SELECT id, role, organisation_id FROM registrations WHERE supabase_user_id = $1`,
+			identity.SupabaseUserID,
+		).Scan(&userCtx.RegistrationID, &userCtx.Role, &userCtx.OrganisationID)
+
+		if errors.Is(err, pgx.ErrNoRows) && identity.Email != "" {
+			// One-time linking: a registration made before sub binding is claimed by the
+			// first verified token carrying its email. Once linked, email is never used again.
+			err = db.Pool.QueryRow(ctx, `
+				UPDATE registrations SET supabase_user_id = $1
+				WHERE email = $2 AND supabase_user_id IS NULL AND NOT is_service_account
+				RETURNING id, role, organisation_id
+			`, identity.SupabaseUserID, identity.Email).Scan(&userCtx.RegistrationID, &userCtx.Role, &userCtx.OrganisationID)
+			if err == nil {
+				logger.Log.Info("Auth middleware: linked registration " + userCtx.RegistrationID + " to Supabase user " + identity.SupabaseUserID)
+			}
+		}
 
 		if err != nil {
 			logger.Log.Error("Auth middleware: user not found in registrations: " + err.Error())
@@ -101,6 +207,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Step 5: Store the authenticated user in Gin's context for handlers to access
+		if authCache != nil {
+			authCache.set(identity.SupabaseUserID, userCtx, generation)
+		}
 		c.Set(UserContextKey, &userCtx)
 		c.Next()
 	}
@@ -135,9 +244,33 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// GetTokenIdentity extracts the TokenIdentity stored by RequireToken.
+// Returns nil if RequireToken was not applied or failed.
+func GetTokenIdentity(c *gin.Context) *TokenIdentity {
+	val, exists := c.Get(TokenIdentityKey)
+	if !exists {
+		return nil
+	}
+	identity, ok := val.(*TokenIdentity)
+	if !ok {
+		return nil
+	}
+	return identity
+}
+
 // GetUser is a helper that extracts the authenticated UserContext from the Gin context.
-// Returns nil if the user is not authenticated (AuthMiddleware was not applied or failed).
+// Returns nil if the user is not authenticated (AuthMiddleware was not applied or failed),
+// or if an API token is used on a route that does not accept it (see RequireScope).
 func GetUser(c *gin.Context) *UserContext {
+	user := getUserContext(c)
+	if user == nil || (user.APITokenID != "" && !user.scopeGranted) {
+		return nil
+	}
+	return user
+}
+
+// getUserContext returns the UserContext stored by AuthMiddleware, whatever the credential.
+func getUserContext(c *gin.Context) *UserContext {
 	val, exists := c.Get(UserContextKey)
 	if !exists {
 		return nil
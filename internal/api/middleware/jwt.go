@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/jwks"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token verification state, configured once from config.Cfg by InitAuth.
+var (
+	authOnce    sync.Once
+	tokenParser *jwt.Parser  // Enforces allowed algorithms and the iss/aud claims
+	keySet      *jwks.KeySet // nil when asymmetric (RS256/ES256) verification is not configured
+)
+
+/*
+InitAuth configures JWT verification from the loaded config:
+  - HS256/HS384/HS512 tokens are verified with SUPABASE_JWT_SECRET (if set)
+  - RS256/ES256 tokens are verified against a JWKS document from SUPABASE_JWKS_FILE
+    or SUPABASE_JWKS_URL (if set), cached for JWKS_CACHE_TTL and selected by "kid"
+  - JWT_ISSUER / JWT_AUDIENCE, when set, must match the token's "iss" / "aud" claims
+  - AUTH_CACHE_ENABLED / AUTH_CACHE_TTL control the registration lookup cache
+
+It is safe to call more than once; only the first call has an effect.
+Must be called from main() after config and logger are initialized, so that configuration
+errors stop the server at startup; the middleware never initializes it lazily.
+*/
+func InitAuth() {
+	authOnce.Do(func() {
+		methods := []string{}
+		if config.Cfg.SupabaseJWTSecret != "" {
+			methods = append(methods, "HS256", "HS384", "HS512")
+		}
+
+		source := config.Cfg.SupabaseJWKSFile
+		if source == "" {
+			source = config.Cfg.SupabaseJWKSURL
+		}
+		if source != "" {
+			ttl, err := time.ParseDuration(config.Cfg.JWKSCacheTTL)
+			if err != nil {
+				logger.Log.Warn("Invalid JWKS_CACHE_TTL, using 10m: " + err.Error())
+				ttl = 10 * time.Minute
+			}
+			keySet = jwks.New(source, ttl)
+			methods = append(methods, "RS256", "ES256")
+
+			// Warm the key cache; a failure here is retried by the first asymmetric token
+			// once the JWKS refresh interval has passed
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := keySet.Refresh(ctx); err != nil {
+				logger.Log.Warn("Unable to load JWKS at startup: " + err.Error())
+			}
+		}
+
+		if len(methods) == 0 {
+			logger.Log.Fatal("No JWT verification configured: set SUPABASE_JWT_SECRET and/or a JWKS source")
+		}
+
+		opts := []jwt.ParserOption{jwt.WithValidMethods(methods)}
+		if config.Cfg.JWTIssuer != "" {
+			opts = append(opts, jwt.WithIssuer(config.Cfg.JWTIssuer))
+		}
+		if config.Cfg.JWTAudience != "" {
+			opts = append(opts, jwt.WithAudience(config.Cfg.JWTAudience))
+		}
+		tokenParser = jwt.NewParser(opts...)
+
+		// Registration lookup cache (see userCache)
+		if config.Cfg.AuthCacheEnabled {
+			ttl, err := time.ParseDuration(config.Cfg.AuthCacheTTL)
+			if err != nil || ttl <= 0 {
+				logger.Log.Warn("Invalid AUTH_CACHE_TTL, using 60s")
+				ttl = time.Minute
+			}
+			authCache = newUserCache(ttl)
+		}
+	})
+}
+
+// verificationKey is the jwt.Keyfunc that selects the key matching the token's algorithm:
+// the shared secret for HMAC tokens, or the JWKS key named by the "kid" header otherwise.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(config.Cfg.SupabaseJWTSecret), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if keySet == nil {
+			return nil, fmt.Errorf("asymmetric signing method %v is not configured", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return keySet.Key(ctx, kid, token.Method.Alg())
+	}
+	return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
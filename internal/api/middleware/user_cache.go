@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxCachedUsers bounds the cache; expired entries are pruned when it is reached.
+const maxCachedUsers = 10000
+
+/*
+userCache is an in-process TTL cache of registration lookups made by AuthMiddleware,
+keyed by the token subject (Supabase user ID). It removes the registrations query from
+hot authenticated endpoints.
+
+Entries are dropped when they expire, and explicitly via InvalidateUser whenever a
+registration's role or organisation changes, the registration is bound to a Supabase user,
+or the user is removed. A lookup that raced with an invalidation is not cached (see
+generation). Because the cache is per process, other instances
+only see such changes once their entry expires, so keep AUTH_CACHE_TTL short.
+*/
+type userCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]userCacheEntry
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+
+	// generation is bumped by every invalidation. A lookup only caches its result if no
+	// invalidation happened since it started, so it cannot store data read before an update.
+	generation atomic.Uint64
+}
+
+type userCacheEntry struct {
+	user    UserContext
+	expires time.Time
+}
+
+// CacheStats is a snapshot of the auth cache counters, exposed to administrators.
+type CacheStats struct {
+	Enabled    bool    `json:"enabled"`
+	Size       int     `json:"size"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	HitRatio   float64 `json:"hit_ratio"`
+	TTLSeconds float64 `json:"ttl_seconds"`
+}
+
+// authCache is nil when AUTH_CACHE_ENABLED is false (set up by InitAuth).
+var authCache *userCache
+
+func newUserCache(ttl time.Duration) *userCache {
+	return &userCache{ttl: ttl, entries: map[string]userCacheEntry{}}
+}
+
+// get returns a copy of the cached user for the subject, if present and not expired.
+func (uc *userCache) get(subject string) (UserContext, bool) {
+	uc.mu.RLock()
+	entry, ok := uc.entries[subject]
+	uc.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		uc.misses.Add(1)
+		return UserContext{}, false
+	}
+	uc.hits.Add(1)
+	return entry.user, true
+}
+
+// currentGeneration returns the invalidation generation to pass to set.
+func (uc *userCache) currentGeneration() uint64 {
+	return uc.generation.Load()
+}
+
+// set stores the user under the subject for the cache TTL, unless an invalidation happened
+// since generation was read (the user may then be stale).
+func (uc *userCache) set(subject string, user UserContext, generation uint64) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if uc.generation.Load() != generation {
+		return
+	}
+
+	if len(uc.entries) >= maxCachedUsers {
+		now := time.Now()
+		for key, entry := range uc.entries {
+			if now.After(entry.expires) {
+				delete(uc.entries, key)
+			}
+		}
+		if len(uc.entries) >= maxCachedUsers {
+			uc.entries = map[string]userCacheEntry{} // Still full of live entries: start over
+		}
+	}
+	uc.entries[subject] = userCacheEntry{user: user, expires: time.Now().Add(uc.ttl)}
+}
+
+// invalidate removes every entry belonging to the registration.
+func (uc *userCache) invalidate(registrationID string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.generation.Add(1)
+	for key, entry := range uc.entries {
+		if entry.user.RegistrationID == registrationID {
+			delete(uc.entries, key)
+		}
+	}
+}
+
+// InvalidateUser drops the cached registration data of a user so that the next request
+// reloads it from the database. Call it after committing any change to a registration's
+// role, organisation or Supabase binding, and after removing a user.
+func InvalidateUser(registrationID string) {
+	if authCache != nil {
+		authCache.invalidate(registrationID)
+	}
+}
+
+// GetCacheStats returns the current auth cache counters.
+func GetCacheStats() CacheStats {
+	if authCache == nil {
+		return CacheStats{Enabled: false}
+	}
+
+	authCache.mu.RLock()
+	size := len(authCache.entries)
+	authCache.mu.RUnlock()
+
+	stats := CacheStats{
+		Enabled:    true,
+		Size:       size,
+		Hits:       authCache.hits.Load(),
+		Misses:     authCache.misses.Load(),
+		TTLSeconds: authCache.ttl.Seconds(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -1,15 +1,22 @@
 /*
 Package router defines all API routes and their middleware chains.
-Routes are grouped under /api/v1 with three access levels:
-  - Public: no authentication required (health check, registration)
-  - Authenticated: requires a valid Supabase JWT
-  - Role-restricted: requires authentication + a specific role (e.g., PM)
+Routes are grouped under /api/v1 with these access levels:
+  - Public: no authentication required (health check)
+  - Token-only: requires a valid Supabase JWT but no registration (registration)
+  - Authenticated: requires a valid Supabase JWT (or, on routes marked with a scope,
+    an API token holding that scope; see middleware.RequireScope)
+  - Role-restricted: requires authentication + a specific role (e.g., PM, Admin)
+
+All project, member and bug data is scoped to the caller's organisation.
+Within a project, permissions come from the caller's project role (owner, maintainer,
+developer, qa, viewer) and are checked by the handlers; see handlers/authz.go.
 */
 package router
 
 import (
 	"github.com/Ankit1974/TaskDeskBackend/internal/api/handlers"
 	"github.com/Ankit1974/TaskDeskBackend/internal/api/middleware"
+	"github.com/Ankit1974/TaskDeskBackend/internal/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,11 +27,68 @@ It returns the configured engine ready to be started with r.Run().
 Route table:
 
 	GET  /api/v1/health     — Public: server and DB health check
-	POST /api/v1/register   — Public: create a new user registration
+	POST /api/v1/register   — Valid JWT (no registration yet): register the token's Supabase user
+	POST /api/v1/invitations/accept — Valid JWT (registration optional): accept an invitation, registering if needed
+	GET  /api/v1/me         — Authenticated (JWT): the user's profile and work counts
+	PATCH /api/v1/me        — Authenticated (JWT): edit own name, avatar, timezone and notification preferences
+	GET  /api/v1/me/mentions           — Authenticated (JWT): @mention inbox (?unread=true, paginated)
+	PATCH /api/v1/me/mentions/:mentionId — Authenticated (JWT): mark a mention read or unread
+	POST /api/v1/me/mentions/read-all  — Authenticated (JWT): mark all mentions read
+	GET  /api/v1/organisation       — Authenticated (JWT): the user's organisation
+	GET  /api/v1/organisation/users — Authenticated (JWT): list the organisation's users
 	GET  /api/v1/projects      — Authenticated: list user's created/assigned projects
-	GET  /api/v1/projects/:id       — Authenticated: get details of a specific project
-	POST /api/v1/projects           — PM only: create a new project
-	POST /api/v1/projects/:id/bugs  — Authenticated: create bugs in a project (batch)
+	GET  /api/v1/projects/:id       — Authenticated [projects:read]: get details of a specific project
+	PATCH /api/v1/projects/:id      — Project owner/maintainer: partially update a project
+	POST /api/v1/projects/:id/archive — Project owner/maintainer: archive (soft delete) a project
+	POST /api/v1/projects/:id/restore — Project owner/maintainer: restore an archived project
+	GET  /api/v1/projects/:id/activity — Authenticated [projects:read]: the project's activity feed (cursor-paginated)
+	GET  /api/v1/activity           — Authenticated [projects:read]: activity of every project the caller can see
+	DELETE /api/v1/projects/:id     — PM or Admin (+ project owner/maintainer): purge an archived project after the retention window
+	PUT  /api/v1/admin/users/:userId/role — Admin only: promote or demote a user's role in the organisation
+	DELETE /api/v1/admin/users/:userId    — Admin only: remove a user's registration
+	GET  /api/v1/admin/auth-cache        — Admin only: auth cache hit/miss counters
+	POST /api/v1/admin/service-accounts  — Admin only: create a service account
+	GET  /api/v1/admin/service-accounts  — Admin only: list service accounts
+	POST   /api/v1/admin/service-accounts/:userId/tokens          — Admin only: issue a service-account API key
+	GET    /api/v1/admin/service-accounts/:userId/tokens          — Admin only: list a service account's keys
+	DELETE /api/v1/admin/service-accounts/:userId/tokens/:tokenId — Admin only: revoke a service-account key
+	POST   /api/v1/tokens           — Authenticated (JWT): create a personal access token
+	GET    /api/v1/tokens           — Authenticated (JWT): list own personal access tokens
+	DELETE /api/v1/tokens/:tokenId  — Authenticated (JWT): revoke a personal access token
+	POST /api/v1/projects           — PM or Admin: create a new project
+	POST /api/v1/projects/:id/bugs  — Authenticated [bugs:write]: create bugs in a project (batch)
+	GET  /api/v1/projects/:id/bugs  — Authenticated [bugs:read]: list a project's bugs (filter, sort, paginate)
+	GET  /api/v1/projects/:id/bugs/:bugRef — Authenticated [bugs:read]: get a bug by UUID or bug_number
+	PATCH /api/v1/projects/:id/bugs/:bugRef/status — Authenticated [bugs:write]: move a bug through the status workflow
+	PUT  /api/v1/projects/:id/bugs/:bugRef/assignee — Authenticated [bugs:write]: assign, reassign or unassign a bug
+	GET  /api/v1/projects/:id/bugs/:bugRef/history  — Authenticated [bugs:read]: list every recorded change of a bug
+	POST   /api/v1/projects/:id/bugs/:bugRef/comments            — Authenticated [bugs:write]: comment on a bug or reply to a comment
+	GET    /api/v1/projects/:id/bugs/:bugRef/comments            — Authenticated [bugs:read]: list a bug's comments with replies (paginated)
+	PATCH  /api/v1/projects/:id/bugs/:bugRef/comments/:commentId — Authenticated [bugs:write]: edit own comment within the edit window
+	DELETE /api/v1/projects/:id/bugs/:bugRef/comments/:commentId — Authenticated [bugs:write]: delete own comment (owner/maintainer: any)
+	POST   /api/v1/projects/:id/bugs/:bugRef/attachments                — Authenticated [bugs:write]: upload files (multipart "files")
+	GET    /api/v1/projects/:id/bugs/:bugRef/attachments                — Authenticated [bugs:read]: list a bug's attachments
+	GET    /api/v1/projects/:id/bugs/:bugRef/attachments/:attachmentId — Authenticated [bugs:read]: download an attachment
+	DELETE /api/v1/projects/:id/bugs/:bugRef/attachments/:attachmentId — Authenticated [bugs:write]: delete own attachment (owner/maintainer: any)
+	GET    /api/v1/projects/:id/bugs/:bugRef/links          — Authenticated [bugs:read]: list a bug's incoming and outgoing links
+	POST   /api/v1/projects/:id/bugs/:bugRef/links          — Authenticated [bugs:write]: link to another bug (duplicate_of closes the bug)
+	DELETE /api/v1/projects/:id/bugs/:bugRef/links/:linkId  — Authenticated [bugs:write]: remove a link
+	GET    /api/v1/projects/:id/labels                        — Authenticated [bugs:read]: list the project's label catalog
+	POST   /api/v1/projects/:id/labels                        — Authenticated [bugs:write]: create a label (owner/maintainer)
+	PATCH  /api/v1/projects/:id/labels/:labelId               — Authenticated [bugs:write]: rename/recolor a label (owner/maintainer)
+	DELETE /api/v1/projects/:id/labels/:labelId               — Authenticated [bugs:write]: delete a label and remove it from bugs (owner/maintainer)
+	POST   /api/v1/projects/:id/bugs/:bugRef/labels           — Authenticated [bugs:write]: add labels to a bug by name
+	DELETE /api/v1/projects/:id/bugs/:bugRef/labels/:labelId — Authenticated [bugs:write]: remove a label from a bug
+	GET    /api/v1/projects/:id/members         — Authenticated: list a project's members
+	POST   /api/v1/projects/:id/members         — Project owner/maintainer: add a member
+	PATCH  /api/v1/projects/:id/members/:userId — Project owner/maintainer: change a member's project role
+	DELETE /api/v1/projects/:id/members/:userId — Project owner/maintainer: remove a member
+	POST   /api/v1/projects/:id/invitations     — Project owner/maintainer: invite an email into the project
+	GET    /api/v1/projects/:id/invitations     — Project owner/maintainer: list the project's invitations
+	POST   /api/v1/invitations                  — PM or Admin: invite an email into the organisation
+	GET    /api/v1/invitations                  — PM or Admin: list the organisation's invitations
+	POST   /api/v1/invitations/:invitationId/resend — PM/Admin or project owner/maintainer: send a new link
+	DELETE /api/v1/invitations/:invitationId        — PM/Admin or project owner/maintainer: revoke an invitation
 */
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
@@ -35,22 +99,106 @@ func SetupRouter() *gin.Engine {
 	{
 		// ── Public routes (no authentication required) ──
 		api.GET("/health", handlers.HealthCheck)
-		api.POST("/register", handlers.Register)
+
+		// ── Token-only routes (valid Supabase JWT, registration not required) ──
+		api.POST("/register", middleware.RequireToken(), handlers.Register)
+		api.POST("/invitations/accept", middleware.RequireToken(), handlers.AcceptInvitation)
 
 		// ── Authenticated routes (valid Supabase JWT required) ──
 		auth := api.Group("")
 		auth.Use(middleware.AuthMiddleware())
 		{
+			// Profile of the authenticated user
+			auth.GET("/me", handlers.GetMe)
+			auth.PATCH("/me", handlers.UpdateMe)
+			auth.GET("/me/mentions", handlers.ListMyMentions)
+			auth.PATCH("/me/mentions/:mentionId", handlers.UpdateMyMention)
+			auth.POST("/me/mentions/read-all", handlers.MarkAllMentionsRead)
+
+			// Organisation of the authenticated user
+			auth.GET("/organisation", handlers.GetOrganisation)
+			auth.GET("/organisation/users", handlers.ListOrganisationUsers)
+
 			// All authenticated users can view their projects
 			auth.GET("/projects", handlers.GetProjects)
-			auth.GET("/projects/:id", handlers.GetProjectByID)
-			auth.POST("/projects/:id/bugs", handlers.CreateBugs)
+			auth.GET("/projects/:id", middleware.RequireScope(model.ScopeProjectsRead), handlers.GetProjectByID)
+			auth.PATCH("/projects/:id", handlers.UpdateProject)
+			auth.POST("/projects/:id/archive", handlers.ArchiveProject)
+			auth.POST("/projects/:id/restore", handlers.RestoreProject)
+			auth.GET("/projects/:id/activity", middleware.RequireScope(model.ScopeProjectsRead), handlers.GetProjectActivity)
+			auth.GET("/activity", middleware.RequireScope(model.ScopeProjectsRead), handlers.GetActivity)
+			auth.POST("/projects/:id/bugs", middleware.RequireScope(model.ScopeBugsWrite), handlers.CreateBugs)
+			auth.GET("/projects/:id/bugs", middleware.RequireScope(model.ScopeBugsRead), handlers.ListBugs)
+			auth.GET("/projects/:id/bugs/:bugRef", middleware.RequireScope(model.ScopeBugsRead), handlers.GetBug)
+			auth.PATCH("/projects/:id/bugs/:bugRef/status", middleware.RequireScope(model.ScopeBugsWrite), handlers.UpdateBugStatus)
+			auth.PUT("/projects/:id/bugs/:bugRef/assignee", middleware.RequireScope(model.ScopeBugsWrite), handlers.UpdateBugAssignee)
+			auth.GET("/projects/:id/bugs/:bugRef/history", middleware.RequireScope(model.ScopeBugsRead), handlers.GetBugHistory)
+
+			// Bug comments
+			auth.POST("/projects/:id/bugs/:bugRef/comments", middleware.RequireScope(model.ScopeBugsWrite), handlers.CreateBugComment)
+			auth.GET("/projects/:id/bugs/:bugRef/comments", middleware.RequireScope(model.ScopeBugsRead), handlers.ListBugComments)
+			auth.PATCH("/projects/:id/bugs/:bugRef/comments/:commentId", middleware.RequireScope(model.ScopeBugsWrite), handlers.UpdateBugComment)
+			auth.DELETE("/projects/:id/bugs/:bugRef/comments/:commentId", middleware.RequireScope(model.ScopeBugsWrite), handlers.DeleteBugComment)
+
+			// Bug attachments
+			auth.POST("/projects/:id/bugs/:bugRef/attachments", middleware.RequireScope(model.ScopeBugsWrite), handlers.UploadBugAttachments)
+			auth.GET("/projects/:id/bugs/:bugRef/attachments", middleware.RequireScope(model.ScopeBugsRead), handlers.ListBugAttachments)
+			auth.GET("/projects/:id/bugs/:bugRef/attachments/:attachmentId", middleware.RequireScope(model.ScopeBugsRead), handlers.DownloadBugAttachment)
+			auth.DELETE("/projects/:id/bugs/:bugRef/attachments/:attachmentId", middleware.RequireScope(model.ScopeBugsWrite), handlers.DeleteBugAttachment)
+
+			// Bug links
+			auth.GET("/projects/:id/bugs/:bugRef/links", middleware.RequireScope(model.ScopeBugsRead), handlers.ListBugLinks)
+			auth.POST("/projects/:id/bugs/:bugRef/links", middleware.RequireScope(model.ScopeBugsWrite), handlers.CreateBugLink)
+			auth.DELETE("/projects/:id/bugs/:bugRef/links/:linkId", middleware.RequireScope(model.ScopeBugsWrite), handlers.DeleteBugLink)
 
-			// ── PM-only routes (JWT + "PM" role required) ──
+			// Labels
+			auth.GET("/projects/:id/labels", middleware.RequireScope(model.ScopeBugsRead), handlers.ListLabels)
+			auth.POST("/projects/:id/labels", middleware.RequireScope(model.ScopeBugsWrite), handlers.CreateLabel)
+			auth.PATCH("/projects/:id/labels/:labelId", middleware.RequireScope(model.ScopeBugsWrite), handlers.UpdateLabel)
+			auth.DELETE("/projects/:id/labels/:labelId", middleware.RequireScope(model.ScopeBugsWrite), handlers.DeleteLabel)
+			auth.POST("/projects/:id/bugs/:bugRef/labels", middleware.RequireScope(model.ScopeBugsWrite), handlers.AddBugLabels)
+			auth.DELETE("/projects/:id/bugs/:bugRef/labels/:labelId", middleware.RequireScope(model.ScopeBugsWrite), handlers.RemoveBugLabel)
+
+			// Membership management (project role checks happen inside the handlers)
+			auth.GET("/projects/:id/members", handlers.ListProjectMembers)
+			auth.POST("/projects/:id/members", handlers.AddProjectMember)
+			auth.PATCH("/projects/:id/members/:userId", handlers.UpdateProjectMember)
+			auth.DELETE("/projects/:id/members/:userId", handlers.RemoveProjectMember)
+
+			// Invitations (organisation invitations need PM/Admin, project invitations a
+			// project owner/maintainer; checked inside the handlers)
+			auth.POST("/projects/:id/invitations", handlers.CreateProjectInvitation)
+			auth.GET("/projects/:id/invitations", handlers.ListProjectInvitations)
+			auth.POST("/invitations/:invitationId/resend", handlers.ResendInvitation)
+			auth.DELETE("/invitations/:invitationId", handlers.RevokeInvitation)
+
+			// Personal access tokens (JWT only: API tokens cannot mint other tokens)
+			auth.POST("/tokens", handlers.CreateAPIToken)
+			auth.GET("/tokens", handlers.ListAPITokens)
+			auth.DELETE("/tokens/:tokenId", handlers.RevokeAPIToken)
+
+			// ── Project management routes (JWT + "PM" or "Admin" role required) ──
 			pm := auth.Group("")
-			pm.Use(middleware.RequireRole("PM"))
+			pm.Use(middleware.RequireRole(model.RolePM, model.RoleAdmin))
 			{
 				pm.POST("/projects", handlers.CreateProject)
+				pm.DELETE("/projects/:id", handlers.PurgeProject)
+				pm.POST("/invitations", handlers.CreateInvitation)
+				pm.GET("/invitations", handlers.ListInvitations)
+			}
+
+			// ── Admin-only routes (JWT + "Admin" role required) ──
+			admin := auth.Group("/admin")
+			admin.Use(middleware.RequireRole(model.RoleAdmin))
+			{
+				admin.PUT("/users/:userId/role", handlers.ChangeUserRole)
+				admin.DELETE("/users/:userId", handlers.DeleteUser)
+				admin.GET("/auth-cache", handlers.GetAuthCacheStats)
+				admin.POST("/service-accounts", handlers.CreateServiceAccount)
+				admin.GET("/service-accounts", handlers.ListServiceAccounts)
+				admin.POST("/service-accounts/:userId/tokens", handlers.CreateServiceAccountToken)
+				admin.GET("/service-accounts/:userId/tokens", handlers.ListServiceAccountTokens)
+				admin.DELETE("/service-accounts/:userId/tokens/:tokenId", handlers.RevokeServiceAccountToken)
 			}
 		}
 	}
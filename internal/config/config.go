@@ -22,15 +22,82 @@ type Config struct {
 	SupabaseKey       string `mapstructure:"SUPABASE_ANON_KEY"`   // Supabase anonymous/public API key
 	SupabaseJWTSecret string `mapstructure:"SUPABASE_JWT_SECRET"` // Supabase JWT signing secret (used to verify access tokens)
 	DatabaseURL       string `mapstructure:"DATABASE_URL"`        // PostgreSQL connection string (Supabase DB)
+
+	// Asymmetric JWT verification (RS256/ES256). Set one JWKS source to enable it;
+	// SUPABASE_JWKS_FILE takes precedence and allows verifying tokens offline.
+	SupabaseJWKSURL  string `mapstructure:"SUPABASE_JWKS_URL"`  // e.g. https://<ref>.supabase.co/auth/v1/.well-known/jwks.json
+	SupabaseJWKSFile string `mapstructure:"SUPABASE_JWKS_FILE"` // Local JWKS document path
+	JWKSCacheTTL     string `mapstructure:"JWKS_CACHE_TTL"`     // How long fetched keys are cached (Go duration, default: "10m")
+	JWTIssuer        string `mapstructure:"JWT_ISSUER"`         // Required "iss" claim (empty = not checked)
+	JWTAudience      string `mapstructure:"JWT_AUDIENCE"`       // Required "aud" claim (empty = not checked), e.g. "authenticated"
+
+	AuthCacheEnabled bool   `mapstructure:"AUTH_CACHE_ENABLED"` // Cache registration lookups in AuthMiddleware (default: true)
+	AuthCacheTTL     string `mapstructure:"AUTH_CACHE_TTL"`     // Cache entry lifetime (Go duration, default: "60s")
+
+	ProjectPurgeRetentionDays int `mapstructure:"PROJECT_PURGE_RETENTION_DAYS"` // Days a project must stay archived before it can be purged (default: 30)
+	CommentEditWindowMinutes  int `mapstructure:"COMMENT_EDIT_WINDOW_MINUTES"`  // Minutes during which authors can edit a bug comment (default: 15, 0 = no limit)
+
+	// Outgoing email (see internal/mailer). MAIL_DRIVER=log only logs messages.
+	MailDriver   string `mapstructure:"MAIL_DRIVER"`   // "log" (default) or "smtp"
+	MailFrom     string `mapstructure:"MAIL_FROM"`     // Sender address, e.g. "TaskDesk <no-reply@example.com>"
+	MailLogDir   string `mapstructure:"MAIL_LOG_DIR"`  // log driver: also write each message to this directory as .eml (empty = log only)
+	SMTPHost     string `mapstructure:"SMTP_HOST"`     // SMTP server host
+	SMTPPort     string `mapstructure:"SMTP_PORT"`     // SMTP server port (default: "587"; "465" uses implicit TLS)
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"` // SMTP username (empty = no authentication)
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"` // SMTP password
+
+	// Blob storage for bug attachments (see internal/storage)
+	StorageDriver     string `mapstructure:"STORAGE_DRIVER"`       // "local" (default) or "s3"
+	StorageLocalDir   string `mapstructure:"STORAGE_LOCAL_DIR"`    // local driver: root directory (default: "./data/attachments")
+	S3Endpoint        string `mapstructure:"S3_ENDPOINT"`          // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	S3Region          string `mapstructure:"S3_REGION"`            // Signing region (default: "us-east-1")
+	S3Bucket          string `mapstructure:"S3_BUCKET"`            // Bucket name
+	S3AccessKeyID     string `mapstructure:"S3_ACCESS_KEY_ID"`     // Access key
+	S3SecretAccessKey string `mapstructure:"S3_SECRET_ACCESS_KEY"` // Secret key
+	S3ForcePathStyle  bool   `mapstructure:"S3_FORCE_PATH_STYLE"`  // Use endpoint/bucket/key URLs (default: true; needed by MinIO)
+
+	AttachmentMaxFileMB int `mapstructure:"ATTACHMENT_MAX_FILE_MB"` // Largest accepted attachment in MiB (default: 25)
+	AttachmentMaxBugMB  int `mapstructure:"ATTACHMENT_MAX_BUG_MB"`  // Total attachment size allowed per bug in MiB (default: 100)
+
+	InvitationTTLHours int    `mapstructure:"INVITATION_TTL_HOURS"` // Hours an invitation link stays valid (default: 72)
+	InviteAcceptURL    string `mapstructure:"INVITE_ACCEPT_URL"`    // Frontend page that accepts invitations; the token is appended as ?token=
 }
 
 // LoadConfig reads configuration from the .env file and environment variables.
-// It sets defaults for APP_PORT and ENV, then populates the global Cfg variable.
+// It sets defaults for optional values (APP_PORT, ENV, ...), then populates the global Cfg variable.
 // Returns the loaded Config pointer.
 func LoadConfig() *Config {
 	// Set defaults for optional values
 	viper.SetDefault("APP_PORT", "8080")
 	viper.SetDefault("ENV", "development")
+	viper.SetDefault("PROJECT_PURGE_RETENTION_DAYS", 30)
+	viper.SetDefault("COMMENT_EDIT_WINDOW_MINUTES", 15)
+	viper.SetDefault("SUPABASE_JWKS_URL", "")
+	viper.SetDefault("SUPABASE_JWKS_FILE", "")
+	viper.SetDefault("JWKS_CACHE_TTL", "10m")
+	viper.SetDefault("JWT_ISSUER", "")
+	viper.SetDefault("JWT_AUDIENCE", "")
+	viper.SetDefault("AUTH_CACHE_ENABLED", true)
+	viper.SetDefault("AUTH_CACHE_TTL", "60s")
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "TaskDesk <no-reply@taskdesk.local>")
+	viper.SetDefault("MAIL_LOG_DIR", "")
+	viper.SetDefault("SMTP_HOST", "")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("SMTP_USERNAME", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./data/attachments")
+	viper.SetDefault("S3_ENDPOINT", "")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_BUCKET", "")
+	viper.SetDefault("S3_ACCESS_KEY_ID", "")
+	viper.SetDefault("S3_SECRET_ACCESS_KEY", "")
+	viper.SetDefault("S3_FORCE_PATH_STYLE", true)
+	viper.SetDefault("ATTACHMENT_MAX_FILE_MB", 25)
+	viper.SetDefault("ATTACHMENT_MAX_BUG_MB", 100)
+	viper.SetDefault("INVITATION_TTL_HOURS", 72)
+	viper.SetDefault("INVITE_ACCEPT_URL", "http://localhost:3000/invite")
 
 	// Read from .env file in the working directory
 	viper.SetConfigFile(".env")
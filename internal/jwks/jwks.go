@@ -0,0 +1,316 @@
+/*
+Package jwks loads JSON Web Key Sets (RFC 7517) used to verify asymmetrically signed
+access tokens (RS256 / ES256). Keys are cached in memory, selected by their "kid",
+and re-fetched when the cache expires or when a token references an unknown key,
+which is how signing key rotation is picked up.
+
+The key set can be read from an HTTPS URL (e.g. Supabase's
+https://<project>.supabase.co/auth/v1/.well-known/jwks.json) or from a local file,
+which makes it possible to verify tokens offline.
+*/
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often an unknown "kid" or a stale cache can trigger a
+// re-fetch, so that tokens with random kids cannot be used to hammer the JWKS endpoint and
+// an unreachable source does not stall every request behind a failing fetch.
+const minRefreshInterval = 30 * time.Second
+
+// maxDocumentSize caps the size of a JWKS document read from a URL or file.
+const maxDocumentSize = 1 << 20 // 1 MiB
+
+// ErrKeyNotFound is returned when no key in the set matches the requested kid/algorithm.
+var ErrKeyNotFound = errors.New("jwks: no matching key found")
+
+// key is a parsed verification key together with the metadata used for selection.
+type key struct {
+	public crypto.PublicKey
+	alg    string // Optional "alg" from the JWK; empty means "any compatible algorithm"
+}
+
+// KeySet is a cached, refreshable set of verification keys. It is safe for concurrent use.
+type KeySet struct {
+	source string        // https://... URL, or a file path (optionally prefixed with file://)
+	ttl    time.Duration // How long fetched keys are considered fresh
+	client *http.Client
+	now    func() time.Time // Clock; replaced in tests
+
+	refreshMu   sync.Mutex // Serialises fetches so concurrent requests trigger only one
+	mu          sync.RWMutex
+	keys        map[string]key // Keyed by "kid" ("" for keys without a kid)
+	fetchedAt   time.Time      // Last successful fetch
+	lastAttempt time.Time      // Last fetch attempt (successful or not)
+}
+
+// New creates a KeySet for the given source. Keys are fetched lazily on first use.
+func New(source string, ttl time.Duration) *KeySet {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &KeySet{
+		source: source,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 5 * time.Second},
+		now:    time.Now,
+		keys:   map[string]key{},
+	}
+}
+
+// Refresh fetches the key set from its source and replaces the cached keys.
+// On failure the previously cached keys are kept.
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	ks.mu.Lock()
+	ks.lastAttempt = ks.now()
+	ks.mu.Unlock()
+
+	data, err := ks.load(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parse(data)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetchedAt = ks.now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// Key returns the public key for the given kid that can verify tokens signed with alg
+// ("RS256" or "ES256"). A missing kid is accepted only when exactly one key matches alg.
+// Stale caches and unknown kids trigger a refresh, at most once per minRefreshInterval.
+func (ks *KeySet) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	// A failed refresh keeps serving the previous keys until the source recovers
+	_ = ks.refreshIf(ctx, func() bool { return ks.now().Sub(ks.fetchedAt) > ks.ttl && ks.canRetry() })
+
+	if pub, err := ks.lookup(kid, alg); !errors.Is(err, ErrKeyNotFound) {
+		return pub, err
+	}
+
+	// Unknown kid: the signing key may have been rotated since the last fetch
+	err := ks.refreshIf(ctx, ks.canRetry)
+	if err != nil {
+		return nil, err
+	}
+	return ks.lookup(kid, alg)
+}
+
+// canRetry reports whether minRefreshInterval has passed since the last fetch attempt.
+// The caller must hold ks.mu.
+func (ks *KeySet) canRetry() bool {
+	return ks.now().Sub(ks.lastAttempt) >= minRefreshInterval
+}
+
+// refreshIf re-fetches the key set when need() reports true. need is evaluated while
+// holding the fetch lock, so requests that waited for a concurrent fetch do not repeat it.
+func (ks *KeySet) refreshIf(ctx context.Context, need func() bool) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	ks.mu.RLock()
+	needed := need()
+	ks.mu.RUnlock()
+	if !needed {
+		return nil
+	}
+	return ks.Refresh(ctx)
+}
+
+// lookup selects a cached key by kid and checks that it is compatible with alg.
+func (ks *KeySet) lookup(kid, alg string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid != "" {
+		k, ok := ks.keys[kid]
+		if !ok || !compatible(k, alg) {
+			return nil, ErrKeyNotFound
+		}
+		return k.public, nil
+	}
+
+	// No kid in the token header: only unambiguous when a single key fits
+	var found crypto.PublicKey
+	for _, k := range ks.keys {
+		if !compatible(k, alg) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("jwks: token has no kid and several keys match %s", alg)
+		}
+		found = k.public
+	}
+	if found == nil {
+		return nil, ErrKeyNotFound
+	}
+	return found, nil
+}
+
+// compatible reports whether a key can verify signatures made with alg.
+func compatible(k key, alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "ES256":
+			return pub.Curve == elliptic.P256()
+		case "ES384":
+			return pub.Curve == elliptic.P384()
+		case "ES512":
+			return pub.Curve == elliptic.P521()
+		}
+	}
+	return false
+}
+
+// load reads the raw JWKS document from a URL or a local file.
+func (ks *KeySet) load(ctx context.Context) ([]byte, error) {
+	if strings.HasPrefix(ks.source, "https://") || strings.HasPrefix(ks.source, "http://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := ks.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: fetch %s: %w", ks.source, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks: fetch %s: unexpected status %d", ks.source, resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	}
+
+	f, err := os.Open(strings.TrimPrefix(ks.source, "file://"))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxDocumentSize))
+}
+
+// jwk is the subset of RFC 7517/7518 fields needed for RSA and EC verification keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA public exponent
+	Crv string `json:"crv"` // EC curve name
+	X   string `json:"x"`   // EC point X coordinate
+	Y   string `json:"y"`   // EC point Y coordinate
+}
+
+// parse decodes a JWKS document. Keys that are not signature keys, or that use
+// unsupported key types, are skipped; malformed supported keys are an error.
+func parse(data []byte) (map[string]key, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("jwks: invalid document: %w", err)
+	}
+
+	keys := make(map[string]key, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var pub crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			pub, err = parseRSA(k)
+		case "EC":
+			pub, err = parseEC(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key{public: pub, alg: k.Alg}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: document contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// parseRSA builds an RSA public key from the base64url-encoded modulus and exponent.
+func parseRSA(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+
+	exponent := int(new(big.Int).SetBytes(e).Int64())
+	if exponent < 3 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
+
+// parseEC builds an ECDSA public key from the curve name and base64url-encoded coordinates.
+// The point is validated to lie on the curve.
+func parseEC(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, errors.New("invalid EC coordinates")
+	}
+
+	// Uncompressed SEC 1 encoding: 0x04 || X || Y
+	point := make([]byte, 0, 1+2*size)
+	point = append(point, 4)
+	point = append(point, x...)
+	point = append(point, y...)
+	return ecdsa.ParseUncompressedPublicKey(curve, point)
+}
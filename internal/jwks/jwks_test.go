@@ -0,0 +1,217 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testKeys are the keys the test cases publish, by name.
+type testKeys struct {
+	rsa1, rsa2 *rsa.PrivateKey
+	ec1        *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsa1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsa2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa1: rsa1, rsa2: rsa2, ec1: ec1}
+}
+
+// public returns the public key published under a name ("rsa1", "rsa2" or "ec1").
+func (k testKeys) public(name string) crypto.PublicKey {
+	switch name {
+	case "rsa1":
+		return &k.rsa1.PublicKey
+	case "rsa2":
+		return &k.rsa2.PublicKey
+	case "ec1":
+		return &k.ec1.PublicKey
+	}
+	return nil
+}
+
+// jwk encodes the named key as a JWK with the given kid.
+func (k testKeys) jwk(t *testing.T, name, kid string) map[string]string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := k.public(name).(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		point, err := pub.Bytes() // 0x04 || X || Y
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (len(point) - 1) / 2
+		return map[string]string{
+			"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256",
+			"x": b64(point[1 : 1+size]), "y": b64(point[1+size:]),
+		}
+	}
+	t.Fatalf("unknown test key %q", name)
+	return nil
+}
+
+// step is one action of a test case: optionally change the JWKS file and the clock,
+// then look up a key.
+type step struct {
+	publish map[string]string // kid → test key name; nil keeps the file as is
+	remove  bool              // Delete the file, so that fetches fail
+	advance time.Duration     // Move the clock forward before the lookup
+
+	kid, alg string
+	want     string // Name of the expected key; "" expects wantErr
+	wantErr  error
+}
+
+func TestKeySet(t *testing.T) {
+	keys := newTestKeys(t)
+	const ttl = 10 * time.Minute
+
+	tests := []struct {
+		name    string
+		publish map[string]string // Initial JWKS document: kid → test key name
+		steps   []step
+	}{
+		{
+			name:    "known kid",
+			publish: map[string]string{"k1": "rsa1", "k2": "ec1"},
+			steps: []step{
+				{kid: "k1", alg: "RS256", want: "rsa1"},
+				{kid: "k2", alg: "ES256", want: "ec1"},
+			},
+		},
+		{
+			name:    "unknown kid triggers a refresh",
+			publish: map[string]string{"k1": "rsa1"},
+			steps: []step{
+				{kid: "k1", alg: "RS256", want: "rsa1"},
+				{publish: map[string]string{"k1": "rsa1", "k2": "rsa2"}, advance: minRefreshInterval,
+					kid: "k2", alg: "RS256", want: "rsa2"},
+			},
+		},
+		{
+			name:    "unknown kid refreshes at most once per interval",
+			publish: map[string]string{"k1": "rsa1"},
+			steps: []step{
+				{kid: "k1", alg: "RS256", want: "rsa1"},
+				{publish: map[string]string{"k1": "rsa1", "k2": "rsa2"}, advance: time.Second,
+					kid: "k2", alg: "RS256", wantErr: ErrKeyNotFound},
+				{advance: minRefreshInterval, kid: "k2", alg: "RS256", want: "rsa2"},
+			},
+		},
+		{
+			name:    "rotated key",
+			publish: map[string]string{"k1": "rsa1"},
+			steps: []step{
+				{kid: "k1", alg: "RS256", want: "rsa1"},
+				{publish: map[string]string{"k2": "rsa2"}, advance: ttl + time.Second,
+					kid: "k2", alg: "RS256", want: "rsa2"},
+				{kid: "k1", alg: "RS256", wantErr: ErrKeyNotFound},
+			},
+		},
+		{
+			name:    "alg mismatch",
+			publish: map[string]string{"k1": "rsa1", "k2": "ec1"},
+			steps: []step{
+				{kid: "k1", alg: "ES256", wantErr: ErrKeyNotFound},
+				{kid: "k2", alg: "RS256", wantErr: ErrKeyNotFound},
+			},
+		},
+		{
+			name:    "ttl expiry while the source is failing",
+			publish: map[string]string{"k1": "rsa1"},
+			steps: []step{
+				{kid: "k1", alg: "RS256", want: "rsa1"},
+				{remove: true, advance: ttl + time.Second, kid: "k1", alg: "RS256", want: "rsa1"},
+				{advance: time.Second, kid: "k1", alg: "RS256", want: "rsa1"},
+				{advance: minRefreshInterval, kid: "k1", alg: "RS256", want: "rsa1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			publish := func(kids map[string]string) {
+				doc := struct {
+					Keys []map[string]string `json:"keys"`
+				}{}
+				for kid, name := range kids {
+					doc.Keys = append(doc.Keys, keys.jwk(t, name, kid))
+				}
+				data, err := json.Marshal(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, data, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			publish(tt.publish)
+
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			ks := New("file://"+path, ttl)
+			ks.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				if s.publish != nil {
+					publish(s.publish)
+				}
+				if s.remove {
+					if err := os.Remove(path); err != nil {
+						t.Fatal(err)
+					}
+				}
+				now = now.Add(s.advance)
+				attemptsBefore := ks.lastAttempt
+
+				pub, err := ks.Key(context.Background(), s.kid, s.alg)
+				if s.want == "" {
+					if !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d: Key(%q, %q) error = %v, want %v", i, s.kid, s.alg, err, s.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("step %d: Key(%q, %q) error = %v", i, s.kid, s.alg, err)
+				}
+				if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(keys.public(s.want)) {
+					t.Fatalf("step %d: Key(%q, %q) returned the wrong key, want %s", i, s.kid, s.alg, s.want)
+				}
+
+				// Fetches never happen more often than minRefreshInterval
+				if ks.lastAttempt != attemptsBefore && !attemptsBefore.IsZero() &&
+					ks.lastAttempt.Sub(attemptsBefore) < minRefreshInterval {
+					t.Fatalf("step %d: refetched %s after the previous attempt", i, ks.lastAttempt.Sub(attemptsBefore))
+				}
+			}
+		})
+	}
+}
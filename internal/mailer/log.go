@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+	"go.uber.org/zap"
+)
+
+// unsafeFileChars matches characters that are replaced in .eml file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]`)
+
+// LogSender is the development sender: it logs every message (including its body, so links
+// such as invitation URLs can be copied from the log) and, when Dir is set, also writes
+// the message to Dir as an .eml file that mail clients can open.
+type LogSender struct {
+	Dir string
+}
+
+// Send logs the message and writes the optional .eml file.
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	logger.Log.Info("Mailer: email (not sent)",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	if s.Dir == "" {
+		return nil
+	}
+
+	data, err := format("taskdesk@localhost", msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(s.Dir, name), data, 0o644)
+}
@@ -0,0 +1,79 @@
+/*
+Package mailer sends transactional email (e.g. invitations) through a pluggable Sender.
+
+Two implementations are provided:
+  - SMTPSender delivers through an SMTP server (STARTTLS on 587, implicit TLS on 465)
+  - LogSender logs every message and optionally writes it as an .eml file, for development
+
+Usage from any package: mailer.Default.Send(ctx, mailer.Message{...}).
+Must be initialized via InitMailer() before use.
+*/
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Default is the application's configured sender, set by InitMailer.
+var Default Sender
+
+// InitMailer selects the sender from MAIL_DRIVER: "smtp" or "log" (the default).
+func InitMailer(cfg *config.Config) {
+	switch strings.ToLower(cfg.MailDriver) {
+	case "smtp":
+		sender, err := NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+		if err != nil {
+			logger.Log.Fatal(fmt.Sprintf("Invalid SMTP mailer configuration: %v", err))
+		}
+		Default = sender
+		logger.Log.Info("Mailer: sending email via SMTP " + cfg.SMTPHost + ":" + cfg.SMTPPort)
+	default:
+		Default = &LogSender{Dir: cfg.MailLogDir}
+		logger.Log.Info("Mailer: email is logged, not sent (MAIL_DRIVER=log)")
+	}
+}
+
+// errHeaderInjection is returned for header values that contain line breaks.
+var errHeaderInjection = errors.New("mailer: header value contains a line break")
+
+// format renders a message as an RFC 5322 document with UTF-8 plain-text content.
+func format(from string, msg Message) ([]byte, error) {
+	for _, v := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPSender delivers email through an SMTP server. Port 465 uses implicit TLS; any other
+// port upgrades with STARTTLS when the server offers it. Authentication (PLAIN) is used
+// when a username is set, and requires TLS. Create it with NewSMTPSender.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string // From header, e.g. "TaskDesk <no-reply@example.com>"
+
+	envelopeFrom string // Bare address of From, for MAIL FROM
+}
+
+// NewSMTPSender validates the configuration and returns an SMTPSender. from may include a
+// display name; only its address is used as the envelope sender.
+func NewSMTPSender(host, port, username, password, from string) (*SMTPSender, error) {
+	if host == "" || from == "" {
+		return nil, errors.New("SMTP_HOST and MAIL_FROM must be set")
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM %q: %w", from, err)
+	}
+	return &SMTPSender{
+		Host:         host,
+		Port:         port,
+		Username:     username,
+		Password:     password,
+		From:         from,
+		envelopeFrom: addr.Address,
+	}, nil
+}
+
+// Send delivers the message. The context bounds the whole SMTP conversation.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if s.envelopeFrom == "" {
+		return errors.New("mailer: SMTPSender must be created with NewSMTPSender")
+	}
+	data, err := format(s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+	addr := net.JoinHostPort(s.Host, s.Port)
+	tlsConfig := &tls.Config{ServerName: s.Host}
+
+	var conn net.Conn
+	if s.Port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.Port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.envelopeFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
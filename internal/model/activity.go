@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types of the project activity feed.
+const (
+	ActivityProjectCreated    = "project.created"     // payload: project_name
+	ActivityProjectUpdated    = "project.updated"     // payload: fields (names of the changed fields)
+	ActivityProjectArchived   = "project.archived"    // payload: {}
+	ActivityProjectRestored   = "project.restored"    // payload: {}
+	ActivityMemberAdded       = "member.added"        // payload: user_id, user_name, role
+	ActivityMemberJoined      = "member.joined"       // payload: user_id, role (accepted an invitation; the actor is the new member)
+	ActivityMemberRoleChanged = "member.role_changed" // payload: user_id, user_name, role
+	ActivityMemberRemoved     = "member.removed"      // payload: user_id
+	ActivityBugCreated        = "bug.created"         // payload: bug_number, title
+	ActivityBugStatusChanged  = "bug.status_changed"  // payload: bug_number, from, to
+	ActivityBugAssigned       = "bug.assigned"        // payload: bug_number, from, to (registration ids, null = unassigned)
+	ActivityBugLabelsChanged  = "bug.labels_changed"  // payload: bug_number, added, removed (label names)
+	ActivityBugLinked         = "bug.linked"          // payload: bug_number, link_id, type, target_bug_number (type relative to the bug)
+	ActivityBugUnlinked       = "bug.unlinked"        // payload: bug_number, link_id, type, target_bug_number
+	ActivityCommentCreated    = "comment.created"     // payload: bug_number, comment_id, parent_id
+)
+
+// ActivityEventTypes lists every event type, for validating the type filter.
+var ActivityEventTypes = []string{
+	ActivityProjectCreated, ActivityProjectUpdated, ActivityProjectArchived, ActivityProjectRestored,
+	ActivityMemberAdded, ActivityMemberJoined, ActivityMemberRoleChanged, ActivityMemberRemoved,
+	ActivityBugCreated, ActivityBugStatusChanged, ActivityBugAssigned, ActivityBugLabelsChanged,
+	ActivityBugLinked, ActivityBugUnlinked, ActivityCommentCreated,
+}
+
+// ActivityEvent is one entry of a project's activity feed.
+type ActivityEvent struct {
+	ID          int64           `json:"id" db:"id"`
+	ProjectID   string          `json:"project_id" db:"project_id"`
+	ProjectName string          `json:"project_name"`
+	EventType   string          `json:"event_type" db:"event_type"`
+	ActorID     string          `json:"actor_id" db:"actor_id"`
+	ActorName   string          `json:"actor_name"`
+	BugID       *string         `json:"bug_id" db:"bug_id"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ActivityFeedResponse wraps a page of activity, newest first. NextCursor is passed as
+// ?cursor= to fetch the following (older) page; it is null on the last page.
+type ActivityFeedResponse struct {
+	Activity   []ActivityEvent `json:"activity"`
+	NextCursor *string         `json:"next_cursor"`
+	Limit      int             `json:"limit"`
+}
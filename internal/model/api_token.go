@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// API token scopes. A token can only call routes that require one of its scopes
+// (see middleware.RequireScope).
+const (
+	ScopeProjectsRead = "projects:read" // Read project details
+	ScopeBugsRead     = "bugs:read"     // List and read bugs
+	ScopeBugsWrite    = "bugs:write"    // Create bugs and change their status
+)
+
+// APITokenPrefix starts every API token, which tells them apart from Supabase JWTs.
+const APITokenPrefix = "tdk_"
+
+/*
+APIToken is a personal access token or a service-account API key.
+The token secret is never stored or returned after creation; only its hash is kept.
+*/
+type APIToken struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"` // The registration the token acts as
+	Name        string     `json:"name" db:"name"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"` // First characters of the token, for identification
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	ProjectID   *string    `json:"project_id" db:"project_id"` // nil = any project the owner can access
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedBy   *string    `json:"created_by" db:"created_by"` // nil once the creator was removed
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateAPITokenRequest is the JSON body for creating a personal or service-account token.
+// ExpiresInDays defaults to 90.
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=projects:read bugs:read bugs:write"`
+	ProjectID     *string  `json:"project_id" binding:"omitnil,uuid"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// CreateAPITokenResponse returns the new token. Token is the secret to send as
+// "Authorization: Bearer <token>"; it cannot be retrieved again.
+type CreateAPITokenResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// APITokenListResponse wraps a list of tokens (without secrets).
+type APITokenListResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// CreateServiceAccountRequest is the JSON body for POST /api/v1/admin/service-accounts.
+// Role defaults to DefaultRole; service accounts cannot hold privileged roles.
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Role string `json:"role"`
+}
+
+// ServiceAccountListResponse wraps a list of service accounts.
+type ServiceAccountListResponse struct {
+	ServiceAccounts []Registration `json:"service_accounts"`
+}
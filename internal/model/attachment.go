@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// BugAttachment is a file attached to a bug. The content is fetched through
+// GET /api/v1/projects/:id/bugs/:bugRef/attachments/:attachmentId.
+type BugAttachment struct {
+	ID             string    `json:"id" db:"id"`
+	BugID          string    `json:"bug_id" db:"bug_id"`
+	FileName       string    `json:"file_name" db:"file_name"`
+	ContentType    string    `json:"content_type" db:"content_type"` // Sniffed from the content
+	SizeBytes      int64     `json:"size_bytes" db:"size_bytes"`
+	SHA256         string    `json:"sha256" db:"sha256"`
+	UploadedBy     string    `json:"uploaded_by" db:"uploaded_by"`
+	UploadedByName string    `json:"uploaded_by_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	StorageKey     string    `json:"-" db:"storage_key"` // Never exposed to clients
+}
+
+// BugAttachmentListResponse wraps attachments (oldest first) with the bug's quota usage.
+// GET lists all of the bug's attachments; POST returns only the ones it uploaded.
+type BugAttachmentListResponse struct {
+	Attachments []BugAttachment `json:"attachments"`
+	TotalCount  int             `json:"total_count"` // Number of attachments returned
+	TotalBytes  int64           `json:"total_bytes"` // Size of all of the bug's attachments
+	LimitBytes  int64           `json:"limit_bytes"` // ATTACHMENT_MAX_BUG_MB in bytes
+}
@@ -3,41 +3,127 @@ package model
 import "time"
 
 // CreateBugRequest represents a single bug in the batch creation request.
+// Length limits mirror the column sizes of the bugs table.
 type CreateBugRequest struct {
-	Title       string   `json:"title" binding:"required"`
+	Title       string   `json:"title" binding:"required,max=255"`
 	Priority    string   `json:"priority" binding:"required,oneof=critical high medium low"`
 	Description string   `json:"description"`
 	Steps       []string `json:"steps"`
-	Version     string   `json:"version"`
-	Platform    string   `json:"platform"`
-	AssignedTo  string   `json:"assigned_to"` // Optional registration UUID
+	Version     string   `json:"version" binding:"max=50"`
+	Platform    string   `json:"platform" binding:"max=100"`
+	AssignedTo  string   `json:"assigned_to" binding:"omitempty,uuid"` // Optional registration UUID
+	Labels      []string `json:"labels"`                               // Optional label names from the project's catalog
 }
 
 // CreateBugsRequest wraps an array of bugs for POST /api/v1/projects/:id/bugs.
+// Individual bugs are validated one by one by the handler (not via "dive") so that
+// every invalid entry can be reported with its index.
 type CreateBugsRequest struct {
-	Bugs []CreateBugRequest `json:"bugs" binding:"required,min=1,max=20,dive"`
+	Bugs []CreateBugRequest `json:"bugs" binding:"required,min=1,max=20"`
+}
+
+// BugItemResult reports the outcome of one entry of a batch creation request.
+// Status is "created" (Bug is set), "rejected" (Error explains why) or, when an
+// all-or-nothing batch is refused, "valid" for the entries that had no problem.
+type BugItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Bug    *Bug   `json:"bug,omitempty"`
+	Error  string `json:"error,omitempty"`
 }
 
 // Bug represents a full bug record in the database.
 type Bug struct {
-	ID          string    `json:"id" db:"id"`
-	ProjectID   string    `json:"project_id" db:"project_id"`
-	BugNumber   string    `json:"bug_number" db:"bug_number"`
-	Title       string    `json:"title" db:"title"`
-	Priority    string    `json:"priority" db:"priority"`
-	Description *string   `json:"description" db:"description"`
-	Steps       []string  `json:"steps" db:"steps"`
-	Version     *string   `json:"version,omitempty" db:"version"`
-	Platform    *string   `json:"platform,omitempty" db:"platform"`
-	Status      string    `json:"status" db:"status"`
-	CreatedBy   string    `json:"created_by" db:"created_by"`
-	AssignedTo  *string   `json:"assigned_to" db:"assigned_to"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string     `json:"id" db:"id"`
+	ProjectID   string     `json:"project_id" db:"project_id"`
+	BugNumber   string     `json:"bug_number" db:"bug_number"`
+	Title       string     `json:"title" db:"title"`
+	Priority    string     `json:"priority" db:"priority"`
+	Description *string    `json:"description" db:"description"`
+	Steps       []string   `json:"steps" db:"steps"`
+	Version     *string    `json:"version,omitempty" db:"version"`
+	Platform    *string    `json:"platform,omitempty" db:"platform"`
+	Status      string     `json:"status" db:"status"`
+	Resolution  *string    `json:"resolution" db:"resolution"`
+	CreatedBy   string     `json:"created_by" db:"created_by"`
+	AssignedTo  *string    `json:"assigned_to" db:"assigned_to"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	Labels      []BugLabel `json:"labels,omitempty"`   // Sorted by name; included in bug lists, details and creation responses
+	Mentions    []Mention  `json:"mentions,omitempty"` // Mentions in the description; returned when the bug is created
 }
 
 // CreateBugsResponse wraps the created bugs array for the API response.
+// Results is only included for partial batches (?partial=true).
 type CreateBugsResponse struct {
-	Bugs  []Bug `json:"bugs"`
-	Count int   `json:"count"`
+	Bugs    []Bug           `json:"bugs"`
+	Count   int             `json:"count"`
+	Results []BugItemResult `json:"results,omitempty"`
+}
+
+// BugListResponse wraps a paginated list of bugs for GET /api/v1/projects/:id/bugs.
+type BugListResponse struct {
+	Bugs       []Bug `json:"bugs"`
+	TotalCount int   `json:"total_count"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+}
+
+// BugDetail is the response for GET /api/v1/projects/:id/bugs/:bugRef.
+// It embeds the full bug record and adds the display names of the people involved
+// and the bug's links to other bugs.
+type BugDetail struct {
+	Bug
+	ReporterName string   `json:"reporter_name"`
+	AssigneeName *string  `json:"assignee_name"`
+	Links        BugLinks `json:"links"`
+}
+
+// UpdateBugStatusRequest is the JSON body for PATCH /api/v1/projects/:id/bugs/:bugRef/status.
+// Moving a resolved or closed bug back to "open" requires reopen=true.
+type UpdateBugStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=open in_progress resolved closed"`
+	Reopen bool   `json:"reopen"`
+	Note   string `json:"note" binding:"max=2000"` // Optional resolution note
+}
+
+// BugStatusChange is a single recorded status transition of a bug.
+type BugStatusChange struct {
+	ID         string    `json:"id" db:"id"`
+	BugID      string    `json:"bug_id" db:"bug_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Note       *string   `json:"note" db:"note"`
+	ChangedBy  string    `json:"changed_by" db:"changed_by"`
+	ChangedAt  time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// UpdateBugStatusResponse returns the updated bug together with the recorded change.
+type UpdateBugStatusResponse struct {
+	Bug    Bug             `json:"bug"`
+	Change BugStatusChange `json:"change"`
+}
+
+// UpdateBugAssigneeRequest is the JSON body for PUT /api/v1/projects/:id/bugs/:bugRef/assignee.
+// assigned_to must be a member of the project (the owner included); null or omitted unassigns the bug.
+type UpdateBugAssigneeRequest struct {
+	AssignedTo *string `json:"assigned_to" binding:"omitnil,uuid"`
+	Note       string  `json:"note" binding:"max=2000"` // Optional reason for the change
+}
+
+// BugAssignment is a single recorded assignee change of a bug. A nil assignee means unassigned.
+type BugAssignment struct {
+	ID           string    `json:"id" db:"id"`
+	BugID        string    `json:"bug_id" db:"bug_id"`
+	FromAssignee *string   `json:"from_assignee" db:"from_assignee"`
+	ToAssignee   *string   `json:"to_assignee" db:"to_assignee"`
+	Note         *string   `json:"note" db:"note"`
+	AssignedBy   string    `json:"assigned_by" db:"assigned_by"`
+	AssignedAt   time.Time `json:"assigned_at" db:"assigned_at"`
+}
+
+// UpdateBugAssigneeResponse returns the updated bug together with the recorded change.
+type UpdateBugAssigneeResponse struct {
+	Bug    Bug           `json:"bug"`
+	Change BugAssignment `json:"change"`
 }
@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+/*
+BugComment is a comment on a bug. Top-level comments carry their replies (one level of
+threading); replies never have replies of their own.
+Deleted comments keep their place in the thread, but Body is nil.
+*/
+type BugComment struct {
+	ID         string       `json:"id" db:"id"`
+	BugID      string       `json:"bug_id" db:"bug_id"`
+	ParentID   *string      `json:"parent_id" db:"parent_id"` // nil for top-level comments
+	AuthorID   string       `json:"author_id" db:"author_id"`
+	AuthorName string       `json:"author_name"`
+	Body       *string      `json:"body" db:"body"` // nil once deleted
+	EditedAt   *time.Time   `json:"edited_at" db:"edited_at"`
+	DeletedAt  *time.Time   `json:"deleted_at" db:"deleted_at"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	Replies    []BugComment `json:"replies,omitempty"`  // Top-level comments only
+	Mentions   []Mention    `json:"mentions,omitempty"` // Returned when the comment is created or edited
+}
+
+// CreateBugCommentRequest is the JSON body for POST /api/v1/projects/:id/bugs/:bugRef/comments.
+// parent_id makes the comment a reply; it must name a top-level comment of the same bug.
+type CreateBugCommentRequest struct {
+	Body     string  `json:"body" binding:"required,max=10000"`
+	ParentID *string `json:"parent_id" binding:"omitnil,uuid"`
+}
+
+// UpdateBugCommentRequest is the JSON body for PATCH /api/v1/projects/:id/bugs/:bugRef/comments/:commentId.
+type UpdateBugCommentRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
+
+// BugCommentListResponse wraps a page of top-level comments (oldest first) with their replies.
+// TotalCount counts top-level comments.
+type BugCommentListResponse struct {
+	Comments   []BugComment `json:"comments"`
+	TotalCount int          `json:"total_count"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+}
+
+// Mention is an "@handle" found in a bug description or comment. Resolved mentions name a
+// project member (UserID) and land in their inbox; unresolved ones are flagged and not stored.
+type Mention struct {
+	Handle   string  `json:"handle"`
+	UserID   *string `json:"user_id"`
+	Resolved bool    `json:"resolved"`
+}
+
+// MentionInboxItem is an entry of GET /api/v1/me/mentions.
+type MentionInboxItem struct {
+	ID              string     `json:"id" db:"id"`
+	BugID           string     `json:"bug_id" db:"bug_id"`
+	BugNumber       string     `json:"bug_number" db:"bug_number"`
+	BugTitle        string     `json:"bug_title" db:"bug_title"`
+	ProjectID       string     `json:"project_id" db:"project_id"`
+	ProjectName     string     `json:"project_name" db:"project_name"`
+	CommentID       *string    `json:"comment_id" db:"comment_id"` // nil = mentioned in the bug description
+	Excerpt         string     `json:"excerpt"`                    // Start of the text containing the mention
+	MentionedBy     string     `json:"mentioned_by" db:"mentioned_by"`
+	MentionedByName string     `json:"mentioned_by_name"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	ReadAt          *time.Time `json:"read_at" db:"read_at"`
+}
+
+// MentionInboxResponse wraps a page of the mention inbox, newest first.
+type MentionInboxResponse struct {
+	Mentions    []MentionInboxItem `json:"mentions"`
+	TotalCount  int                `json:"total_count"`
+	UnreadCount int                `json:"unread_count"`
+	Page        int                `json:"page"`
+	Limit       int                `json:"limit"`
+}
+
+// UpdateMentionRequest is the JSON body for PATCH /api/v1/me/mentions/:mentionId.
+type UpdateMentionRequest struct {
+	Read *bool `json:"read" binding:"required"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BugHistoryEntry is one recorded change of a bug field. OldValue and NewValue hold the JSON
+// value of the field (null = unset); creation entries have a null OldValue.
+type BugHistoryEntry struct {
+	ID            int64           `json:"id" db:"id"`
+	BugID         string          `json:"bug_id" db:"bug_id"`
+	Field         string          `json:"field" db:"field"`
+	OldValue      json.RawMessage `json:"old_value" db:"old_value"`
+	NewValue      json.RawMessage `json:"new_value" db:"new_value"`
+	ChangedBy     string          `json:"changed_by" db:"changed_by"`
+	ChangedByName string          `json:"changed_by_name"`
+	ChangedAt     time.Time       `json:"changed_at" db:"changed_at"`
+}
+
+// BugHistoryResponse wraps a page of a bug's history for GET /api/v1/projects/:id/bugs/:bugRef/history.
+type BugHistoryResponse struct {
+	History    []BugHistoryEntry `json:"history"`
+	TotalCount int               `json:"total_count"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+}
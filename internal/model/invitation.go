@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Invitation states, computed from the invitation's timestamps.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+/*
+Invitation invites an email address to an organisation, and optionally into one of its
+projects with a project role. The invitation token is only ever sent by email; only its
+hash is stored.
+*/
+type Invitation struct {
+	ID             string     `json:"id" db:"id"`
+	OrganisationID string     `json:"organisation_id" db:"organisation_id"`
+	Email          string     `json:"email" db:"email"`
+	ProjectID      *string    `json:"project_id" db:"project_id"`     // nil = organisation-only invitation
+	ProjectName    *string    `json:"project_name" db:"project_name"` // Joined from projects
+	ProjectRole    *string    `json:"project_role" db:"project_role"` // Set for project invitations
+	Status         string     `json:"status"`                         // pending, accepted, revoked or expired
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at" db:"accepted_at"`
+	AcceptedBy     *string    `json:"accepted_by" db:"accepted_by"`
+	RevokedAt      *time.Time `json:"revoked_at" db:"revoked_at"`
+	SendCount      int        `json:"send_count" db:"send_count"`
+	LastSentAt     time.Time  `json:"last_sent_at" db:"last_sent_at"`
+	InvitedBy      string     `json:"invited_by" db:"invited_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+/*
+CreateInvitationRequest is the JSON body for POST /api/v1/invitations (organisation)
+and POST /api/v1/projects/:id/invitations (project).
+
+Validation rules:
+  - email: required, the address the invitation is sent to
+  - role:  project invitations only; optional project role (default "developer")
+*/
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Role  string `json:"role" binding:"omitempty,oneof=maintainer developer qa viewer"`
+}
+
+// InvitationResponse returns an invitation after it was created or resent.
+// EmailSent is false when delivery failed; the invitation can be resent later.
+type InvitationResponse struct {
+	Invitation
+	EmailSent bool `json:"email_sent"`
+}
+
+// InvitationListResponse wraps a list of invitations.
+type InvitationListResponse struct {
+	Invitations []Invitation `json:"invitations"`
+	Count       int          `json:"count"`
+}
+
+/*
+AcceptInvitationRequest is the JSON body for POST /api/v1/invitations/accept.
+The caller must be signed in with the invited email address. full_name is required
+when the invitee has no registration yet.
+*/
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	FullName string `json:"full_name" binding:"omitempty,max=255"`
+}
+
+// AcceptInvitationResponse returns the invitee's registration and the accepted invitation.
+type AcceptInvitationResponse struct {
+	User       Registration `json:"user"`
+	Invitation Invitation   `json:"invitation"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Label is an entry of a project's label catalog.
+type Label struct {
+	ID          string    `json:"id" db:"id"`
+	ProjectID   string    `json:"project_id" db:"project_id"`
+	Name        string    `json:"name" db:"name"`
+	Color       string    `json:"color" db:"color"` // "#rrggbb"
+	Description *string   `json:"description" db:"description"`
+	BugCount    int       `json:"bug_count"` // Number of bugs carrying the label
+	CreatedBy   string    `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BugLabel is a label as shown on a bug.
+type BugLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// CreateLabelRequest is the JSON body for POST /api/v1/projects/:id/labels.
+// Names are unique within the project (ignoring case) and cannot contain commas.
+type CreateLabelRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Color       string `json:"color" binding:"required"` // "#rrggbb"
+	Description string `json:"description" binding:"max=255"`
+}
+
+// UpdateLabelRequest is the JSON body for PATCH /api/v1/projects/:id/labels/:labelId.
+// Only the fields present are changed; an empty description clears it.
+type UpdateLabelRequest struct {
+	Name        *string `json:"name" binding:"omitnil,max=50"`
+	Color       *string `json:"color"`
+	Description *string `json:"description" binding:"omitnil,max=255"`
+}
+
+// LabelListResponse wraps a project's label catalog, sorted by name.
+type LabelListResponse struct {
+	Labels     []Label `json:"labels"`
+	TotalCount int     `json:"total_count"`
+}
+
+// AddBugLabelsRequest is the JSON body for POST /api/v1/projects/:id/bugs/:bugRef/labels.
+// Labels are referenced by name (case-insensitive) and must exist in the project's catalog.
+type AddBugLabelsRequest struct {
+	Labels []string `json:"labels" binding:"required,min=1"`
+}
+
+// BugLabelsResponse returns a bug's labels after they were changed.
+type BugLabelsResponse struct {
+	BugID  string     `json:"bug_id"`
+	Labels []BugLabel `json:"labels"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Bug link types, as stored (from the source bug's point of view).
+const (
+	BugLinkDuplicateOf = "duplicate_of" // Source is a duplicate of target
+	BugLinkBlocks      = "blocks"       // Source blocks target
+	BugLinkRelatesTo   = "relates_to"   // Undirected
+)
+
+// LinkedBug is the bug at the other end of a link.
+type LinkedBug struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id"`
+	BugNumber string `json:"bug_number"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+}
+
+// BugLink is a link as seen from one of its bugs. Type is relative to that bug:
+// duplicate_of, blocks and relates_to for outgoing links; duplicated_by, blocked_by
+// and relates_to for incoming ones.
+type BugLink struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Direction string    `json:"direction"` // "outgoing" or "incoming"
+	Bug       LinkedBug `json:"bug"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BugLinks lists a bug's links, oldest first. Links to bugs of projects the caller
+// cannot see are left out.
+type BugLinks struct {
+	Outgoing []BugLink `json:"outgoing"`
+	Incoming []BugLink `json:"incoming"`
+}
+
+// CreateBugLinkRequest is the JSON body for POST /api/v1/projects/:id/bugs/:bugRef/links.
+// The target is a bug UUID or bug_number; bug numbers are looked up in TargetProjectID
+// (default: the bug's own project). blocked_by stores a "blocks" link in the other direction.
+type CreateBugLinkRequest struct {
+	Type            string `json:"type" binding:"required,oneof=duplicate_of blocks blocked_by relates_to"`
+	TargetBug       string `json:"target_bug" binding:"required,max=100"`
+	TargetProjectID string `json:"target_project_id" binding:"omitempty,uuid"`
+}
+
+// CreateBugLinkResponse returns the new link. Bug is set when the bug was closed as a
+// duplicate by the link.
+type CreateBugLinkResponse struct {
+	Link BugLink `json:"link"`
+	Bug  *Bug    `json:"bug,omitempty"`
+}
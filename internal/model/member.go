@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Project roles. The project creator is always the implicit "owner"; members hold one of
+// the other roles (project_members.role). What each role may do is defined in handlers/authz.go.
+const (
+	ProjectRoleOwner      = "owner"
+	ProjectRoleMaintainer = "maintainer" // Manages the project and its members, triages bugs
+	ProjectRoleDeveloper  = "developer"  // Files and works on bugs (default for new members)
+	ProjectRoleQA         = "qa"         // Files, assigns, closes and reopens bugs
+	ProjectRoleViewer     = "viewer"     // Read-only
+)
+
+// ProjectMember is a row of project_members joined with the member's registration.
+type ProjectMember struct {
+	ProjectID  string    `json:"project_id" db:"project_id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	FullName   string    `json:"full_name" db:"full_name"`
+	Email      string    `json:"email" db:"email"`
+	Role       string    `json:"role" db:"role"`
+	AssignedAt time.Time `json:"assigned_at" db:"assigned_at"`
+}
+
+/*
+AddProjectMemberRequest is the JSON body for POST /api/v1/projects/:id/members.
+
+Validation rules:
+  - user_id: required, registration UUID of the user to add
+  - role:    optional project role (default "developer"); "owner" is reserved for the creator
+*/
+type AddProjectMemberRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Role   string `json:"role" binding:"omitempty,oneof=maintainer developer qa viewer"`
+}
+
+// UpdateProjectMemberRequest is the JSON body for PATCH /api/v1/projects/:id/members/:userId.
+type UpdateProjectMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=maintainer developer qa viewer"`
+}
+
+// ProjectMemberListResponse wraps the members of a project for GET /api/v1/projects/:id/members.
+type ProjectMemberListResponse struct {
+	Members []ProjectMember `json:"members"`
+	Count   int             `json:"count"`
+}
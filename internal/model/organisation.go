@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Organisation is a tenant. Every registration and every project belongs to one organisation,
+// and users only ever see projects, members and bugs of their own organisation.
+type Organisation struct {
+	ID           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	UserCount    int       `json:"user_count"`
+	ProjectCount int       `json:"project_count"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// OrganisationUserListResponse wraps the users of an organisation for GET /api/v1/organisation/users.
+type OrganisationUserListResponse struct {
+	Users []Registration `json:"users"`
+	Count int            `json:"count"`
+}
@@ -0,0 +1,53 @@
+package model
+
+// NotificationPreferences selects which events a user is notified about.
+type NotificationPreferences struct {
+	BugAssigned      bool `json:"bug_assigned"`       // A bug was assigned to the user
+	BugStatusChanged bool `json:"bug_status_changed"` // A bug the user reported or is assigned changed status
+	Mentioned        bool `json:"mentioned"`          // The user was @mentioned
+	CommentAdded     bool `json:"comment_added"`      // A bug the user reported or is assigned was commented on
+}
+
+// ProfileStats counts the authenticated user's work.
+type ProfileStats struct {
+	OwnedProjects    int `json:"owned_projects"`     // Projects the user created
+	Memberships      int `json:"memberships"`        // Projects the user is a member of
+	OpenAssignedBugs int `json:"open_assigned_bugs"` // Assigned bugs that are open or in progress
+	ReportedBugs     int `json:"reported_bugs"`      // Bugs the user reported
+}
+
+// Profile is the response of GET and PATCH /api/v1/me: the user's registration with the
+// editable profile fields and their counts.
+type Profile struct {
+	Registration
+	AvatarURL               *string                 `json:"avatar_url" db:"avatar_url"`
+	Timezone                string                  `json:"timezone" db:"timezone"`
+	NotificationPreferences NotificationPreferences `json:"notification_preferences" db:"notification_preferences"`
+	Stats                   ProfileStats            `json:"stats"`
+}
+
+// NotificationPreferencesPatch changes only the preferences that are present.
+type NotificationPreferencesPatch struct {
+	BugAssigned      *bool `json:"bug_assigned,omitempty"`
+	BugStatusChanged *bool `json:"bug_status_changed,omitempty"`
+	Mentioned        *bool `json:"mentioned,omitempty"`
+	CommentAdded     *bool `json:"comment_added,omitempty"`
+}
+
+/*
+UpdateProfileRequest is the JSON body for PATCH /api/v1/me.
+Every field is optional; only the fields present in the body are changed.
+
+Special cases:
+  - avatar_url: an http(s) URL; "" removes the avatar
+  - timezone:   an IANA time zone name, e.g. "Europe/Berlin"
+  - role, email: rejected; roles are changed by an Admin and the email comes from the login
+*/
+type UpdateProfileRequest struct {
+	FullName                *string                       `json:"full_name" binding:"omitnil,min=1,max=255"`
+	AvatarURL               *string                       `json:"avatar_url" binding:"omitnil,max=2048"`
+	Timezone                *string                       `json:"timezone" binding:"omitnil,min=1,max=64"`
+	NotificationPreferences *NotificationPreferencesPatch `json:"notification_preferences"`
+	Role                    *string                       `json:"role"`
+	Email                   *string                       `json:"email"`
+}
@@ -13,6 +13,7 @@ import "time"
 	  - icon:         required, must be one of the allowed Material Icon names
 	  - teams:        required, each entry must be a valid team key
 	  - start_date:   optional, expected format: "YYYY-MM-DD"
+	  - bug_prefix:   optional, 1-10 letters/digits starting with a letter (default "BUG")
 */
 type CreateProjectRequest struct {
 	ProjectName string `json:"project_name" binding:"required"`
@@ -20,6 +21,7 @@ type CreateProjectRequest struct {
 	// Icon        string   `json:"icon" binding:"required,oneof=language smartphone cloud storage cloud-upload"`
 	Teams     []string `json:"teams" binding:"required,dive,oneof=backend frontend mobile qa uiux"`
 	StartDate string   `json:"start_date" binding:"omitempty"`
+	BugPrefix string   `json:"bug_prefix" binding:"omitempty,max=10"` // Optional, defaults to "BUG"
 }
 
 /*
@@ -27,22 +29,26 @@ Project represents a full project record in the database.
 Used as the API response after project creation.
 
 Server-generated fields (not sent by the client):
-  - ID, Status, WorkspaceID, CreatedBy, Progress, MemberCount, CreatedAt, UpdatedAt
+  - ID, Status, WorkspaceID, BugPrefix (defaulted), OrganisationID, CreatedBy, Progress, MemberCount,
+    CreatedAt, UpdatedAt
 */
 type Project struct {
-	ID          string    `json:"id" db:"id"`
-	ProjectName string    `json:"project_name" db:"project_name"`
-	Description string    `json:"description" db:"description"`
-	Icon        string    `json:"icon" db:"icon"`
-	Teams       []string  `json:"teams" db:"teams"`
-	StartDate   *string   `json:"start_date,omitempty" db:"start_date"`
-	Status      string    `json:"status" db:"status"`
-	WorkspaceID string    `json:"workspace_id" db:"workspace_id"`
-	CreatedBy   string    `json:"created_by" db:"created_by"`
-	Progress    int       `json:"progress" db:"progress"`
-	MemberCount int       `json:"member_count" db:"member_count"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID             string     `json:"id" db:"id"`
+	ProjectName    string     `json:"project_name" db:"project_name"`
+	Description    string     `json:"description" db:"description"`
+	Icon           string     `json:"icon" db:"icon"`
+	Teams          []string   `json:"teams" db:"teams"`
+	StartDate      *string    `json:"start_date,omitempty" db:"start_date"`
+	Status         string     `json:"status" db:"status"`
+	WorkspaceID    string     `json:"workspace_id" db:"workspace_id"`
+	BugPrefix      string     `json:"bug_prefix" db:"bug_prefix"`
+	OrganisationID string     `json:"organisation_id" db:"organisation_id"` // The creator's organisation
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	Progress       int        `json:"progress" db:"progress"`
+	MemberCount    int        `json:"member_count" db:"member_count"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty" db:"archived_at"` // Set while the project is archived
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // ProjectListResponse wraps a paginated list of projects for GET /api/v1/projects.
@@ -52,3 +58,23 @@ type ProjectListResponse struct {
 	Page       int       `json:"page"`
 	Limit      int       `json:"limit"`
 }
+
+/*
+UpdateProjectRequest is the JSON body for PATCH /api/v1/projects/:id.
+Every field is optional; only the fields present in the body are changed.
+Present fields follow the same rules as CreateProjectRequest.
+
+Special cases:
+  - start_date: "" clears the date
+  - status:     one of active, planning, on_hold, completed
+  - icon:       one of the allowed Material Icon names
+*/
+type UpdateProjectRequest struct {
+	ProjectName *string   `json:"project_name" binding:"omitnil,min=1,max=255"`
+	Description *string   `json:"description" binding:"omitnil,min=1"`
+	Icon        *string   `json:"icon" binding:"omitnil,oneof=language smartphone cloud storage cloud-upload"`
+	Teams       *[]string `json:"teams" binding:"omitnil,dive,oneof=backend frontend mobile qa uiux"`
+	StartDate   *string   `json:"start_date"`
+	Status      *string   `json:"status" binding:"omitnil,oneof=active planning on_hold completed"`
+	BugPrefix   *string   `json:"bug_prefix" binding:"omitnil,max=10"`
+}
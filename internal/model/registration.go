@@ -3,7 +3,10 @@
 // and database row mapping via struct tags (`json`, `db`, `binding`).
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 /*
 Registration represents a user registration record.
@@ -13,12 +16,80 @@ Struct tags:
   - `json`:    JSON field name for API request/response serialization
   - `db`:      Database column name for row scanning
   - `binding`: Gin validation rules (e.g., "required", "email")
+
+Registration requires a Supabase JWT: email and supabase_user_id come from the token.
+organisation_name creates a new organisation, whose founder becomes its Admin; names
+already taken are rejected (existing organisations are joined by invitation).
+Role is always set by the server. Other users' roles are granted by their organisation's
+Admin through the admin role endpoint.
 */
 type Registration struct {
 	ID               string    `json:"id" db:"id"`
 	FullName         string    `json:"full_name" db:"full_name" binding:"required"`
-	Email            string    `json:"email" db:"email" binding:"required,email"`
-	OrganisationName string    `json:"organisation_name" db:"organisation_name" binding:"required"`
-	Role             string    `json:"role" db:"role" binding:"required"`
+	Email            string    `json:"email" db:"email" binding:"omitempty,email"` // Taken from the JWT; optional in the body
+	OrganisationID   string    `json:"organisation_id" db:"organisation_id"`       // Set by the server, never from the body
+	OrganisationName string    `json:"organisation_name" db:"organisation_name" binding:"required,max=255"`
+	Role             string    `json:"role" db:"role"`
+	SupabaseUserID   string    `json:"supabase_user_id" db:"supabase_user_id"`     // Set from the JWT "sub" claim, never from the body
+	IsServiceAccount bool      `json:"is_service_account" db:"is_service_account"` // Authenticates with API tokens only
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
+
+// Global roles stored in registrations.role.
+const (
+	RoleAdmin     = "Admin"     // Manages users and roles
+	RolePM        = "PM"        // Creates and manages projects
+	RoleDeveloper = "Developer" // Default role for self-registered users
+	RoleQA        = "QA"
+	RoleDesigner  = "Designer"
+)
+
+// DefaultRole is assigned when no role is requested (e.g. service accounts).
+const DefaultRole = RoleDeveloper
+
+// knownRoles lists every valid global role and whether it is privileged.
+// Privileged roles cannot be self-assigned at registration.
+var knownRoles = map[string]bool{
+	RoleAdmin:     true,
+	RolePM:        true,
+	RoleDeveloper: false,
+	RoleQA:        false,
+	RoleDesigner:  false,
+}
+
+// NormalizeRole returns the canonical spelling of a known role (case-insensitive match),
+// and false if the role is not known.
+func NormalizeRole(role string) (string, bool) {
+	for known := range knownRoles {
+		if strings.EqualFold(known, strings.TrimSpace(role)) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// IsPrivilegedRole reports whether a canonical role may only be granted by an administrator.
+func IsPrivilegedRole(role string) bool {
+	return knownRoles[role]
+}
+
+// ChangeRoleRequest is the JSON body for PUT /api/v1/admin/users/:userId/role.
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleChange records a change of a user's global role made by an administrator.
+type RoleChange struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	OldRole   string    `json:"old_role" db:"old_role"`
+	NewRole   string    `json:"new_role" db:"new_role"`
+	ChangedBy *string   `json:"changed_by" db:"changed_by"` // nil once the Admin was removed
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// ChangeRoleResponse returns the updated registration together with the recorded change.
+type ChangeRoleResponse struct {
+	User   Registration `json:"user"`
+	Change RoleChange   `json:"change"`
+}
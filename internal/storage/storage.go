@@ -0,0 +1,80 @@
+/*
+Package storage stores binary objects (e.g. bug attachments) behind a pluggable Store.
+
+Two implementations are provided:
+  - LocalStore keeps objects as files below a directory (STORAGE_DRIVER=local, the default)
+  - S3Store keeps objects in an S3-compatible bucket (AWS S3, MinIO, R2, ...)
+
+Usage from any package: storage.Default.Put(ctx, key, r, size, contentType).
+Must be initialized via InitStorage() before use.
+*/
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Ankit1974/TaskDeskBackend/internal/config"
+	"github.com/Ankit1974/TaskDeskBackend/internal/logger"
+)
+
+// ErrNotFound is returned by Get when no object is stored under the key.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Store reads and writes objects by key. Keys are slash-separated relative paths such as
+// "bugs/<bug id>/<attachment id>" and are always generated by the server.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Default is the application's configured store, set by InitStorage.
+var Default Store
+
+// InitStorage selects the store from STORAGE_DRIVER: "local" (the default) or "s3".
+// It will fatally exit if the selected store is misconfigured (fail-fast on startup).
+func InitStorage(cfg *config.Config) {
+	switch strings.ToLower(cfg.StorageDriver) {
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			ForcePathStyle:  cfg.S3ForcePathStyle,
+		})
+		if err != nil {
+			logger.Log.Fatal(fmt.Sprintf("Invalid S3 storage configuration: %v", err))
+		}
+		Default = store
+		logger.Log.Info("Storage: S3 bucket " + cfg.S3Bucket)
+	default:
+		store, err := NewLocalStore(cfg.StorageLocalDir)
+		if err != nil {
+			logger.Log.Fatal(fmt.Sprintf("Unable to use local storage directory: %v", err))
+		}
+		Default = store
+		logger.Log.Info("Storage: local directory " + cfg.StorageLocalDir)
+	}
+}
+
+// validKey reports whether a key is a clean relative path that stays inside the store.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+-- ============================================================================
+-- Migration: Create bug_status_changes table
+-- Records every status transition made through PATCH /projects/:id/bugs/:bugRef/status.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+-- Latest resolution note shown on the bug itself (set when resolved/closed, cleared on reopen)
+ALTER TABLE bugs ADD COLUMN IF NOT EXISTS resolution TEXT;
+
+CREATE TABLE IF NOT EXISTS bug_status_changes (
+    -- Primary key: auto-generated UUID
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Bug whose status changed
+    bug_id       UUID NOT NULL,
+
+    -- Transition
+    from_status  VARCHAR(20) NOT NULL,
+    to_status    VARCHAR(20) NOT NULL,
+    note         TEXT,                                    -- Optional resolution note
+
+    -- Who made the change and when
+    changed_by   UUID NOT NULL,                           -- FK to registrations
+    changed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_bsc_bug        FOREIGN KEY (bug_id)     REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bsc_changed_by FOREIGN KEY (changed_by) REFERENCES registrations(id),
+    CONSTRAINT chk_bsc_from      CHECK (from_status IN ('open', 'in_progress', 'resolved', 'closed')),
+    CONSTRAINT chk_bsc_to        CHECK (to_status IN ('open', 'in_progress', 'resolved', 'closed'))
+);
+
+-- Index for listing a bug's status history in order
+CREATE INDEX IF NOT EXISTS idx_bug_status_changes_bug_id ON bug_status_changes(bug_id, changed_at);
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- Migration: Per-project bug counter and configurable bug prefix
+-- Replaces the MAX(bug_number) scan in CreateBugs with a counter that is
+-- incremented atomically inside the bug-creating transaction.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS bug_prefix VARCHAR(10) NOT NULL DEFAULT 'BUG'; -- e.g. "BUG" → "BUG-42"
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS bug_seq    INTEGER     NOT NULL DEFAULT 0;     -- Last allocated bug number
+
+ALTER TABLE projects ADD CONSTRAINT chk_bug_prefix CHECK (bug_prefix ~ '^[A-Z][A-Z0-9]{0,9}$');
+ALTER TABLE projects ADD CONSTRAINT chk_bug_seq    CHECK (bug_seq >= 0);
+
+-- Backfill the counter from the numbers already issued (e.g. "BUG-17" → 17)
+UPDATE projects p
+SET bug_seq = COALESCE((
+    SELECT MAX(CAST(SUBSTRING(b.bug_number FROM '-([0-9]+)$') AS INTEGER))
+    FROM bugs b
+    WHERE b.project_id = p.id
+), 0);
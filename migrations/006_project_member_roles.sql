@@ -0,0 +1,16 @@
+-- ============================================================================
+-- Migration: Restrict project member roles
+-- The membership API only writes the roles below; normalise anything else.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+UPDATE project_members SET role = LOWER(role);
+UPDATE project_members SET role = 'member'
+WHERE role NOT IN ('pm', 'developer', 'qa', 'designer', 'member');
+
+ALTER TABLE project_members ADD CONSTRAINT chk_pm_role
+    CHECK (role IN ('pm', 'developer', 'qa', 'designer', 'member'));
+
+-- Bring member_count in line with the actual memberships
+UPDATE projects p
+SET member_count = (SELECT COUNT(*) FROM project_members m WHERE m.project_id = p.id);
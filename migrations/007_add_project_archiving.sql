@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Migration: Project archiving (soft delete)
+-- Archived projects keep all their data but are hidden from listings by default.
+-- They can be restored, or permanently purged after the retention window.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ; -- NULL = active
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_by UUID;        -- Who archived it
+
+ALTER TABLE projects ADD CONSTRAINT fk_archived_by
+    FOREIGN KEY (archived_by) REFERENCES registrations(id) ON DELETE SET NULL;
+
+-- Index for the retention sweep / purge checks
+CREATE INDEX IF NOT EXISTS idx_projects_archived_at ON projects(archived_at) WHERE archived_at IS NOT NULL;
@@ -0,0 +1,45 @@
+-- ============================================================================
+-- Migration: Known registration roles and role change audit
+-- Public registration can no longer self-assign PM/Admin; those roles are granted
+-- by an Admin through PUT /api/v1/admin/users/:userId/role, which is recorded here.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+-- Normalise existing roles to their canonical spelling; unknown values become the default role
+UPDATE registrations SET role = CASE LOWER(role)
+    WHEN 'admin'     THEN 'Admin'
+    WHEN 'pm'        THEN 'PM'
+    WHEN 'developer' THEN 'Developer'
+    WHEN 'qa'        THEN 'QA'
+    WHEN 'designer'  THEN 'Designer'
+    ELSE 'Developer'
+END;
+
+ALTER TABLE registrations ADD CONSTRAINT chk_registration_role
+    CHECK (role IN ('Admin', 'PM', 'Developer', 'QA', 'Designer'));
+
+CREATE TABLE IF NOT EXISTS role_changes (
+    -- Primary key: auto-generated UUID
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Whose role changed, from what to what
+    user_id     UUID NOT NULL,
+    old_role    VARCHAR(50) NOT NULL,
+    new_role    VARCHAR(50) NOT NULL,
+
+    -- The Admin who made the change and when (NULL once that Admin was removed)
+    changed_by  UUID,
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_rc_user       FOREIGN KEY (user_id)    REFERENCES registrations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_rc_changed_by FOREIGN KEY (changed_by) REFERENCES registrations(id) ON DELETE SET NULL
+);
+
+-- Index for a user's role history
+CREATE INDEX IF NOT EXISTS idx_role_changes_user_id ON role_changes(user_id, changed_at);
+
+-- NOTE: PM roles that were self-assigned before this migration are kept. Review them with
+--   SELECT id, email, role FROM registrations WHERE role IN ('PM', 'Admin');
+-- and bootstrap the first administrator manually:
+--   UPDATE registrations SET role = 'Admin' WHERE email = '<admin email>';
@@ -0,0 +1,18 @@
+-- ============================================================================
+-- Migration: Bind registrations to the Supabase user ID
+-- AuthMiddleware looks users up by the JWT "sub" claim instead of "email".
+-- Existing rows keep supabase_user_id NULL until the user's first authenticated
+-- request, when they are linked once by email (see middleware.AuthMiddleware).
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS supabase_user_id UUID; -- auth.users.id (JWT "sub")
+
+-- One registration per Supabase user; also serves the AuthMiddleware lookup
+CREATE UNIQUE INDEX IF NOT EXISTS uq_registrations_supabase_user_id
+    ON registrations(supabase_user_id) WHERE supabase_user_id IS NOT NULL;
+
+-- Optional: link existing rows up front instead of on first login
+-- UPDATE registrations r SET supabase_user_id = u.id
+-- FROM auth.users u
+-- WHERE r.supabase_user_id IS NULL AND LOWER(u.email) = LOWER(r.email);
@@ -0,0 +1,47 @@
+-- ============================================================================
+-- Migration: Personal access tokens and service accounts
+-- API tokens let CI jobs and QA automation call the API without a Supabase login.
+-- Only the SHA-256 hash of a token is stored; the token itself is shown once at creation.
+-- Service accounts are registrations without a Supabase identity that can only
+-- authenticate with API tokens issued by an Admin.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    -- Primary key: auto-generated UUID
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- The registration the token acts as (a user or a service account)
+    user_id       UUID NOT NULL,
+    name          VARCHAR(100) NOT NULL,
+
+    -- SHA-256 (hex) of the full token, and its first characters to tell tokens apart
+    token_hash    CHAR(64) NOT NULL,
+    token_prefix  VARCHAR(16) NOT NULL,
+
+    -- What the token may do, optionally limited to a single project
+    scopes        TEXT[] NOT NULL,
+    project_id    UUID,
+
+    -- Lifecycle
+    expires_at    TIMESTAMPTZ NOT NULL,
+    last_used_at  TIMESTAMPTZ,
+    revoked_at    TIMESTAMPTZ,
+    created_by    UUID,                                   -- NULL once the creator was removed (their tokens are revoked first)
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT uq_api_token_hash     UNIQUE (token_hash),
+    CONSTRAINT fk_api_token_user     FOREIGN KEY (user_id)    REFERENCES registrations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_token_project  FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_token_creator  FOREIGN KEY (created_by) REFERENCES registrations(id) ON DELETE SET NULL,
+    CONSTRAINT chk_api_token_scopes  CHECK (
+        cardinality(scopes) > 0
+        AND scopes <@ ARRAY['projects:read', 'bugs:read', 'bugs:write']::TEXT[]
+    )
+);
+
+-- Index for listing a user's tokens
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id, created_at);
@@ -0,0 +1,23 @@
+-- ============================================================================
+-- Migration: Project-scoped roles
+-- Permissions inside a project are now granted by the member's project role
+-- (see handlers/authz.go). The project creator is always the implicit "owner";
+-- members hold one of: maintainer, developer, qa, viewer.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE project_members DROP CONSTRAINT IF EXISTS chk_pm_role;
+
+-- Map the previous roles. "designer" and "member" could file bugs before, so they become
+-- developers to keep that ability.
+UPDATE project_members SET role = CASE role
+    WHEN 'pm'        THEN 'maintainer'
+    WHEN 'developer' THEN 'developer'
+    WHEN 'qa'        THEN 'qa'
+    ELSE 'developer'
+END;
+
+ALTER TABLE project_members ALTER COLUMN role SET DEFAULT 'developer';
+
+ALTER TABLE project_members ADD CONSTRAINT chk_pm_role
+    CHECK (role IN ('maintainer', 'developer', 'qa', 'viewer'));
@@ -0,0 +1,70 @@
+-- ============================================================================
+-- Migration: Organisations (tenants)
+-- Replaces the free-text registrations.organisation_name with an organisations table.
+-- Every registration and every project belongs to exactly one organisation, and all
+-- project, member and bug access is limited to the caller's organisation.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS organisations (
+    -- Primary key: auto-generated UUID
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Display name; unique regardless of case so a second signup cannot claim it
+    name        VARCHAR(255) NOT NULL,
+
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_organisations_name ON organisations(LOWER(name));
+
+-- 1. One organisation per distinct free-text name (case and surrounding spaces ignored)
+INSERT INTO organisations (name)
+SELECT DISTINCT ON (LOWER(TRIM(organisation_name))) TRIM(organisation_name)
+FROM registrations
+WHERE TRIM(COALESCE(organisation_name, '')) <> ''
+ORDER BY LOWER(TRIM(organisation_name)), created_at
+ON CONFLICT DO NOTHING;
+
+-- 2. Attach registrations to their organisation
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS organisation_id UUID;
+
+UPDATE registrations r SET organisation_id = o.id
+FROM organisations o
+WHERE LOWER(o.name) = LOWER(TRIM(r.organisation_name));
+
+-- Registrations without a name each get a personal organisation. It is created by id, and
+-- its name includes the registration id, so it can never be matched to (and merged with)
+-- an existing organisation; a name clash aborts the migration instead (no ON CONFLICT).
+UPDATE registrations SET organisation_id = gen_random_uuid()
+WHERE organisation_id IS NULL;
+
+INSERT INTO organisations (id, name)
+SELECT r.organisation_id, LEFT(r.email, 180) || ' (' || r.id || ')'
+FROM registrations r
+WHERE NOT EXISTS (SELECT 1 FROM organisations o WHERE o.id = r.organisation_id);
+
+ALTER TABLE registrations ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE registrations ADD CONSTRAINT fk_registration_organisation
+    FOREIGN KEY (organisation_id) REFERENCES organisations(id);
+CREATE INDEX IF NOT EXISTS idx_registrations_organisation_id ON registrations(organisation_id);
+
+-- The free-text column is replaced by the organisations table
+ALTER TABLE registrations DROP COLUMN organisation_name;
+
+-- 3. Projects belong to their creator's organisation
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS organisation_id UUID;
+
+UPDATE projects p SET organisation_id = r.organisation_id
+FROM registrations r
+WHERE r.id = p.created_by;
+
+ALTER TABLE projects ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE projects ADD CONSTRAINT fk_project_organisation
+    FOREIGN KEY (organisation_id) REFERENCES organisations(id);
+CREATE INDEX IF NOT EXISTS idx_projects_organisation_id ON projects(organisation_id);
+
+-- NOTE: memberships that already cross organisations are kept. Review them with
+--   SELECT m.project_id, m.user_id FROM project_members m
+--   JOIN projects p ON p.id = m.project_id JOIN registrations r ON r.id = m.user_id
+--   WHERE p.organisation_id <> r.organisation_id;
@@ -0,0 +1,57 @@
+-- ============================================================================
+-- Migration: Invitations
+-- A PM (or a project owner/maintainer) invites an email address to the organisation,
+-- optionally straight into a project with a project role. The invitee receives a
+-- single-use link; accepting it registers them (if needed) and adds the membership.
+-- Only the SHA-256 hash of the invitation token is stored. Resending an invitation
+-- issues a new token and invalidates the previous link.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS invitations (
+    -- Primary key: auto-generated UUID
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Who is invited, and where to (project_id NULL = the organisation only)
+    organisation_id  UUID NOT NULL,
+    email            VARCHAR(255) NOT NULL,
+    project_id       UUID,
+    project_role     VARCHAR(50),
+
+    -- SHA-256 (hex) of the current invitation token
+    token_hash       CHAR(64) NOT NULL,
+
+    -- Lifecycle: an invitation is pending until it is accepted, revoked or expires
+    expires_at       TIMESTAMPTZ NOT NULL,
+    accepted_at      TIMESTAMPTZ,
+    accepted_by      UUID,
+    revoked_at       TIMESTAMPTZ,
+
+    -- Delivery: how often a link was issued, and when the last one was
+    send_count       INTEGER NOT NULL DEFAULT 1,
+    last_sent_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    invited_by       UUID NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT uq_invitation_token_hash  UNIQUE (token_hash),
+    CONSTRAINT fk_invitation_org         FOREIGN KEY (organisation_id) REFERENCES organisations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invitation_project     FOREIGN KEY (project_id)      REFERENCES projects(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invitation_inviter     FOREIGN KEY (invited_by)      REFERENCES registrations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invitation_accepted_by FOREIGN KEY (accepted_by)     REFERENCES registrations(id) ON DELETE SET NULL,
+    CONSTRAINT chk_invitation_role       CHECK (
+        (project_id IS NULL AND project_role IS NULL)
+        OR (project_id IS NOT NULL AND project_role IN ('maintainer', 'developer', 'qa', 'viewer'))
+    )
+);
+
+-- At most one open invitation per email and destination (organisation or project).
+-- Expired invitations stay open until they are resent, revoked or replaced.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_invitations_open
+    ON invitations(organisation_id, LOWER(email), COALESCE(project_id, '00000000-0000-0000-0000-000000000000'::UUID))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- Indexes for listing an organisation's and a project's invitations
+CREATE INDEX IF NOT EXISTS idx_invitations_org_id ON invitations(organisation_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_invitations_project_id ON invitations(project_id, created_at);
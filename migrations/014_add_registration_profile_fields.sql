@@ -0,0 +1,13 @@
+-- ============================================================================
+-- Migration: Profile fields on registrations
+-- Users can edit their own profile through PATCH /api/v1/me: display name, avatar,
+-- timezone and notification preferences. Role and email are not editable there.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS avatar_url TEXT;                            -- http(s) URL, NULL = no avatar
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC'; -- IANA name, e.g. "Europe/Berlin"
+
+-- Which events the user wants to be notified about; every key is always present
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS notification_preferences JSONB NOT NULL
+    DEFAULT '{"bug_assigned": true, "bug_status_changed": true, "mentioned": true, "comment_added": true}'::JSONB;
@@ -0,0 +1,34 @@
+-- ============================================================================
+-- Migration: Create bug_assignments table
+-- Records every assignment, reassignment and unassignment of a bug: the initial
+-- assignee set at creation and every change made through
+-- PUT /projects/:id/bugs/:bugRef/assignee.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS bug_assignments (
+    -- Primary key: auto-generated UUID
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Bug whose assignee changed
+    bug_id         UUID NOT NULL,
+
+    -- Change (NULL = unassigned)
+    from_assignee  UUID,
+    to_assignee    UUID,
+    note           TEXT,                                  -- Optional reason
+
+    -- Who made the change and when
+    assigned_by    UUID NOT NULL,                         -- FK to registrations
+    assigned_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_ba_bug         FOREIGN KEY (bug_id)        REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ba_from        FOREIGN KEY (from_assignee) REFERENCES registrations(id),
+    CONSTRAINT fk_ba_to          FOREIGN KEY (to_assignee)   REFERENCES registrations(id),
+    CONSTRAINT fk_ba_assigned_by FOREIGN KEY (assigned_by)   REFERENCES registrations(id),
+    CONSTRAINT chk_ba_changed    CHECK (from_assignee IS DISTINCT FROM to_assignee)
+);
+
+-- Index for listing a bug's assignment history in order
+CREATE INDEX IF NOT EXISTS idx_bug_assignments_bug_id ON bug_assignments(bug_id, assigned_at);
@@ -0,0 +1,38 @@
+-- ============================================================================
+-- Migration: Create bug_comments table
+-- Discussion on a bug. Comments are top-level or replies to a top-level comment
+-- (one level of threading). Authors can edit their comments for a limited time
+-- (COMMENT_EDIT_WINDOW_MINUTES). Deleting a comment is a soft delete: the row and its
+-- replies are kept, but the body is no longer returned.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS bug_comments (
+    -- Primary key: auto-generated UUID
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Bug being discussed, and the top-level comment this one replies to (NULL = top-level)
+    bug_id      UUID NOT NULL,
+    parent_id   UUID,
+
+    -- Content
+    author_id   UUID NOT NULL,                            -- FK to registrations
+    body        TEXT NOT NULL,
+
+    -- Lifecycle
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at   TIMESTAMPTZ,                              -- Last edit by the author
+    deleted_at  TIMESTAMPTZ,                              -- Soft delete
+    deleted_by  UUID,
+
+    -- Constraints
+    CONSTRAINT fk_bc_bug        FOREIGN KEY (bug_id)     REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bc_parent     FOREIGN KEY (parent_id)  REFERENCES bug_comments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bc_author     FOREIGN KEY (author_id)  REFERENCES registrations(id),
+    CONSTRAINT fk_bc_deleted_by FOREIGN KEY (deleted_by) REFERENCES registrations(id),
+    CONSTRAINT chk_bc_body      CHECK (length(body) BETWEEN 1 AND 10000)
+);
+
+-- Indexes for listing a bug's top-level comments and each comment's replies in order
+CREATE INDEX IF NOT EXISTS idx_bug_comments_bug_id ON bug_comments(bug_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_bug_comments_parent_id ON bug_comments(parent_id, created_at);
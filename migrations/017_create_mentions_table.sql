@@ -0,0 +1,39 @@
+-- ============================================================================
+-- Migration: Create mentions table
+-- "@handle" mentions in bug descriptions and comments, resolved against the project's
+-- members (owner included). Each row is an entry in the mentioned user's inbox
+-- (GET /api/v1/me/mentions) with its read state. Mentions that do not resolve to a
+-- project member are reported in the API response and not stored.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS mentions (
+    -- Primary key: auto-generated UUID
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Where the mention was written (comment_id NULL = the bug description)
+    bug_id             UUID NOT NULL,
+    comment_id         UUID,
+
+    -- Who was mentioned, as written and as resolved
+    handle             VARCHAR(100) NOT NULL,
+    mentioned_user_id  UUID NOT NULL,
+    mentioned_by       UUID NOT NULL,
+
+    -- Inbox state
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    read_at            TIMESTAMPTZ,
+
+    -- Constraints
+    CONSTRAINT fk_mention_bug       FOREIGN KEY (bug_id)            REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_mention_comment   FOREIGN KEY (comment_id)        REFERENCES bug_comments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_mention_user      FOREIGN KEY (mentioned_user_id) REFERENCES registrations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_mention_by        FOREIGN KEY (mentioned_by)      REFERENCES registrations(id) ON DELETE CASCADE
+);
+
+-- A user is mentioned at most once per description or comment (editing a comment only adds new mentions)
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mentions_source_user
+    ON mentions(bug_id, COALESCE(comment_id, '00000000-0000-0000-0000-000000000000'::UUID), mentioned_user_id);
+
+-- Index for the inbox, newest first
+CREATE INDEX IF NOT EXISTS idx_mentions_user ON mentions(mentioned_user_id, created_at DESC);
@@ -0,0 +1,35 @@
+-- ============================================================================
+-- Migration: Create bug_attachments table
+-- Files (screenshots, recordings, logs) attached to a bug. The content lives in the
+-- configured blob store (STORAGE_DRIVER) under storage_key; this table holds the
+-- metadata used for listing, quota checks (ATTACHMENT_MAX_BUG_MB) and downloads.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS bug_attachments (
+    -- Primary key: generated by the server, also the last segment of storage_key
+    id            UUID PRIMARY KEY,
+
+    -- Bug the file is attached to
+    bug_id        UUID NOT NULL,
+
+    -- File metadata
+    file_name     VARCHAR(255) NOT NULL,                  -- Sanitized client file name
+    content_type  VARCHAR(255) NOT NULL,                  -- Sniffed from the content, not the client header
+    size_bytes    BIGINT NOT NULL,
+    sha256        CHAR(64) NOT NULL,                      -- Hex digest of the content
+    storage_key   TEXT NOT NULL,                          -- Key in the blob store: bugs/<bug_id>/<id>
+
+    -- Audit
+    uploaded_by   UUID NOT NULL,                          -- FK to registrations
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_ba_bug         FOREIGN KEY (bug_id)      REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ba_uploaded_by FOREIGN KEY (uploaded_by) REFERENCES registrations(id),
+    CONSTRAINT uq_ba_storage_key UNIQUE (storage_key),
+    CONSTRAINT chk_ba_size       CHECK (size_bytes > 0)
+);
+
+-- Index for listing a bug's attachments in upload order and summing its quota
+CREATE INDEX IF NOT EXISTS idx_bug_attachments_bug_id ON bug_attachments(bug_id, created_at);
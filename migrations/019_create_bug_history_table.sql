@@ -0,0 +1,62 @@
+-- ============================================================================
+-- Migration: Create bug_history table
+-- Append-only audit trail of every change to a bug: one row per changed field with
+-- the old and new values (as JSON, NULL = unset), the acting user and the time.
+-- Creation is recorded as one row per field that was set (old value NULL).
+-- Rows can never be updated, and only disappear when their bug is deleted.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS bug_history (
+    -- Primary key: sequential, so entries written in one transaction keep their order
+    id          BIGSERIAL PRIMARY KEY,
+
+    -- Bug that changed
+    bug_id      UUID NOT NULL,
+
+    -- Change
+    field       VARCHAR(50) NOT NULL,                     -- e.g. "priority", "assigned_to", "attachment"
+    old_value   JSONB,
+    new_value   JSONB,
+
+    -- Who made the change and when
+    changed_by  UUID NOT NULL,                            -- FK to registrations
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_bh_bug        FOREIGN KEY (bug_id)     REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bh_changed_by FOREIGN KEY (changed_by) REFERENCES registrations(id),
+    CONSTRAINT chk_bh_changed   CHECK (old_value IS DISTINCT FROM new_value)
+);
+
+-- Index for listing a bug's history in order
+CREATE INDEX IF NOT EXISTS idx_bug_history_bug_id ON bug_history(bug_id, id);
+
+-- Enforce append-only: updates are always rejected, deletes only happen through the
+-- ON DELETE CASCADE of a deleted bug (the bug row is already gone when the cascade runs)
+CREATE OR REPLACE FUNCTION bug_history_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM bugs WHERE id = OLD.bug_id) THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'bug_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_bug_history_append_only ON bug_history;
+CREATE TRIGGER trg_bug_history_append_only
+    BEFORE UPDATE OR DELETE ON bug_history
+    FOR EACH ROW EXECUTE FUNCTION bug_history_append_only();
+
+-- Backfill from the status and assignment logs kept before this table existed
+INSERT INTO bug_history (bug_id, field, old_value, new_value, changed_by, changed_at)
+SELECT bug_id, 'status', to_jsonb(from_status), to_jsonb(to_status), changed_by, changed_at
+FROM bug_status_changes
+WHERE NOT EXISTS (SELECT 1 FROM bug_history)
+ORDER BY changed_at;
+
+INSERT INTO bug_history (bug_id, field, old_value, new_value, changed_by, changed_at)
+SELECT bug_id, 'assigned_to', to_jsonb(from_assignee), to_jsonb(to_assignee), assigned_by, assigned_at
+FROM bug_assignments
+WHERE NOT EXISTS (SELECT 1 FROM bug_history WHERE field = 'assigned_to')
+ORDER BY assigned_at;
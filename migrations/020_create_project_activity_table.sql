@@ -0,0 +1,32 @@
+-- ============================================================================
+-- Migration: Create project_activity table
+-- Timeline of everything that happens in a project: bugs filed, status changes,
+-- assignments, comments, member changes and project edits. Handlers append one row
+-- per event in the transaction that makes the change. The sequential id doubles as
+-- the pagination cursor of GET /projects/:id/activity and GET /activity.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS project_activity (
+    -- Primary key: sequential, newest events have the highest id
+    id          BIGSERIAL PRIMARY KEY,
+
+    -- Project the event belongs to, and the bug it concerns (if any)
+    project_id  UUID NOT NULL,
+    bug_id      UUID,
+
+    -- Event
+    event_type  VARCHAR(50) NOT NULL,                     -- e.g. "bug.created", "member.joined"
+    actor_id    UUID NOT NULL,                            -- FK to registrations
+    payload     JSONB NOT NULL DEFAULT '{}',              -- Event details (see model.ActivityEvent*)
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_pa_project FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    CONSTRAINT fk_pa_bug     FOREIGN KEY (bug_id)     REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_pa_actor   FOREIGN KEY (actor_id)   REFERENCES registrations(id)
+);
+
+-- Indexes for the project timeline (newest first) and the actor filter
+CREATE INDEX IF NOT EXISTS idx_project_activity_project_id ON project_activity(project_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_project_activity_actor_id ON project_activity(actor_id, id DESC);
@@ -0,0 +1,52 @@
+-- ============================================================================
+-- Migration: Create labels and bug_labels tables
+-- Per-project label catalog ("regression", "ui", "payments", ...) and the
+-- many-to-many link between bugs and labels. Label names are unique within a
+-- project, ignoring case. Deleting a label removes it from every bug.
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS labels (
+    -- Primary key: auto-generated UUID
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Project whose catalog the label belongs to
+    project_id   UUID NOT NULL,
+
+    -- Label fields
+    name         VARCHAR(50) NOT NULL,
+    color        CHAR(7) NOT NULL,                        -- "#rrggbb"
+    description  VARCHAR(255),
+
+    -- Audit
+    created_by   UUID NOT NULL,                           -- FK to registrations
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_label_project    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    CONSTRAINT fk_label_created_by FOREIGN KEY (created_by) REFERENCES registrations(id),
+    CONSTRAINT chk_label_name      CHECK (length(btrim(name)) > 0 AND position(',' IN name) = 0),
+    CONSTRAINT chk_label_color     CHECK (color ~ '^#[0-9a-f]{6}$')
+);
+
+-- Label names are unique per project, case-insensitively
+CREATE UNIQUE INDEX IF NOT EXISTS uq_labels_project_name ON labels(project_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS bug_labels (
+    bug_id    UUID NOT NULL,
+    label_id  UUID NOT NULL,
+
+    -- Who applied the label and when
+    added_by  UUID NOT NULL,                              -- FK to registrations
+    added_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT pk_bug_labels       PRIMARY KEY (bug_id, label_id),
+    CONSTRAINT fk_bl_bug           FOREIGN KEY (bug_id)   REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bl_label         FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bl_added_by      FOREIGN KEY (added_by) REFERENCES registrations(id)
+);
+
+-- Index for the label filter of the bug list
+CREATE INDEX IF NOT EXISTS idx_bug_labels_label_id ON bug_labels(label_id);
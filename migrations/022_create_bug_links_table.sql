@@ -0,0 +1,44 @@
+-- ============================================================================
+-- Migration: Create bug_links table
+-- Typed relationships between two bugs, possibly in different projects of the
+-- same organisation:
+--   duplicate_of: source is a duplicate of target (a bug has at most one)
+--   blocks:       source blocks target (blocking chains cannot form cycles;
+--                 the server checks this before inserting)
+--   relates_to:   undirected; stored once per pair
+-- Run this SQL in your Supabase Dashboard > SQL Editor
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS bug_links (
+    -- Primary key: auto-generated UUID
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- The two bugs and how they relate
+    source_bug_id  UUID NOT NULL,
+    target_bug_id  UUID NOT NULL,
+    link_type      VARCHAR(20) NOT NULL,
+
+    -- Audit
+    created_by     UUID NOT NULL,                         -- FK to registrations
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT fk_bl_source_bug  FOREIGN KEY (source_bug_id) REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bl_target_bug  FOREIGN KEY (target_bug_id) REFERENCES bugs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bl_created_by  FOREIGN KEY (created_by)    REFERENCES registrations(id),
+    CONSTRAINT chk_bl_link_type  CHECK (link_type IN ('duplicate_of', 'blocks', 'relates_to')),
+    CONSTRAINT chk_bl_not_self   CHECK (source_bug_id <> target_bug_id),
+    CONSTRAINT uq_bl_link        UNIQUE (source_bug_id, target_bug_id, link_type)
+);
+
+-- A bug can be a duplicate of only one bug
+CREATE UNIQUE INDEX IF NOT EXISTS uq_bug_links_duplicate_of
+    ON bug_links(source_bug_id) WHERE link_type = 'duplicate_of';
+
+-- relates_to is undirected: (a, b) and (b, a) are the same link
+CREATE UNIQUE INDEX IF NOT EXISTS uq_bug_links_relates_to
+    ON bug_links(LEAST(source_bug_id, target_bug_id), GREATEST(source_bug_id, target_bug_id))
+    WHERE link_type = 'relates_to';
+
+-- Index for the incoming links of a bug (outgoing ones use uq_bl_link)
+CREATE INDEX IF NOT EXISTS idx_bug_links_target ON bug_links(target_bug_id);